@@ -0,0 +1,43 @@
+// bce 以 -gcflags='-m=2 -d=ssa/check_bce/debug=1' 编译包，按函数输出内联决策和剩余的边界检查。
+//
+// 用法：
+//
+//	bce [-tests] [-func regexp] ./highprog
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"highPerformance/pprof/gcdiag"
+)
+
+func main() {
+	tests := flag.Bool("tests", false, "include _test.go files")
+	match := flag.String("func", "", "only report functions matching this regexp")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: bce [-tests] [-func regexp] package")
+		os.Exit(2)
+	}
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	p, err := gcdiag.Compile(flag.Arg(0), *tests, "-m=2", "-d=ssa/check_bce/debug=1")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	funcs := p.Funcs[:0]
+	for _, f := range p.Funcs {
+		if re.MatchString(f.Name) {
+			funcs = append(funcs, f)
+		}
+	}
+	p.Funcs = funcs
+	p.WriteOptimizations(os.Stdout)
+}
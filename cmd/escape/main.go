@@ -0,0 +1,44 @@
+// escape 以 -gcflags=-m=2 编译包，按函数输出逃逸分析结果。
+//
+// 用法：
+//
+//	escape [-tests] [-all] [-func regexp] ./datastruct
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"highPerformance/pprof/gcdiag"
+)
+
+func main() {
+	tests := flag.Bool("tests", false, "include _test.go files")
+	all := flag.Bool("all", false, "also print non-escaping values and inlining decisions")
+	match := flag.String("func", "", "only report functions matching this regexp")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: escape [-tests] [-all] [-func regexp] package")
+		os.Exit(2)
+	}
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	p, err := gcdiag.Compile(flag.Arg(0), *tests)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	funcs := p.Funcs[:0]
+	for _, f := range p.Funcs {
+		if re.MatchString(f.Name) {
+			funcs = append(funcs, f)
+		}
+	}
+	p.Funcs = funcs
+	p.WriteEscapes(os.Stdout, *all)
+}
@@ -0,0 +1,60 @@
+// gotrace 以 GODEBUG=gctrace=1,schedtrace=N 运行程序或测试，输出 GC 和调度器的汇总。
+//
+// 用法：
+//
+//	gotrace [-sched ms] [-json file] -- ./binary args...
+//	gotrace [-sched ms] [-json file] -test ./concurrency -- -test.run=^$ -test.bench=Pool
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"highPerformance/pprof/gotrace"
+)
+
+func main() {
+	sched := flag.Int("sched", 0, "schedtrace interval in milliseconds, 0 disables schedtrace")
+	pkg := flag.String("test", "", "build and run the tests of this package instead of a binary")
+	out := flag.String("json", "", "write parsed events as JSON to this file")
+	flag.Parse()
+	args := flag.Args()
+	if *pkg == "" && len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: gotrace [-sched ms] [-json file] [-test pkg] -- command args...")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var (
+		t   *gotrace.Trace
+		err error
+	)
+	if *pkg != "" {
+		t, err = gotrace.RunTest(ctx, *pkg, *sched, os.Stdout, os.Stderr, args...)
+	} else {
+		t, err = gotrace.Run(ctx, *sched, os.Stdout, os.Stderr, args[0], args[1:]...)
+	}
+	if t == nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	t.Summary(os.Stdout)
+	if *out != "" {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		if werr := os.WriteFile(*out, b, 0644); werr != nil {
+			fmt.Fprintln(os.Stderr, werr)
+			os.Exit(1)
+		}
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,70 @@
+// hpgen 为带有 //hpgen:json 注释的结构体生成不使用反射的 MarshalJSON 和 UnmarshalJSON。
+//
+// 用法：
+//
+//	hpgen [-check] [dir ...]
+//
+// 在结构体定义前加上注释：
+//
+//	//hpgen:json
+//	type Student struct { ... }
+//
+// 然后在包中添加 //go:generate go run highPerformance/cmd/hpgen . 并执行 go generate。
+// -check 只检查生成的文件是否是最新的，不写入文件，适合在 CI 中使用。
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"highPerformance/serialize/hpgen"
+)
+
+func main() {
+	check := flag.Bool("check", false, "report stale generated files instead of writing them")
+	flag.Parse()
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	stale := false
+	for _, dir := range dirs {
+		p, err := hpgen.Load(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		files, err := p.Generate()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			path := filepath.Join(p.Dir, name)
+			if *check {
+				old, _ := os.ReadFile(path)
+				if !bytes.Equal(old, files[name]) {
+					fmt.Fprintf(os.Stderr, "%s is out of date\n", path)
+					stale = true
+				}
+				continue
+			}
+			if err := os.WriteFile(path, files[name], 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+// pgo 合并 CPU profile 为 default.pgo，并对比开启 PGO 前后的基准测试结果。
+//
+// 用法：
+//
+//	pgo -collect -bench 'Fib|Bubble' -count 5 ./benchmark
+//	pgo -profiles pprof/cpu.pprof,other.pprof -bench Unmarshal ./concurrency
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"highPerformance/pprof/pgo"
+)
+
+func main() {
+	profiles := flag.String("profiles", "", "comma-separated CPU profiles to merge")
+	collect := flag.Bool("collect", false, "collect a CPU profile by running the benchmarks first")
+	bench := flag.String("bench", ".", "benchmarks to run")
+	count := flag.Int("count", 5, "number of runs per benchmark")
+	out := flag.String("o", "default.pgo", "merged profile path")
+	verbose := flag.Bool("v", false, "print go test output")
+	flag.Parse()
+	if flag.NArg() != 1 || *profiles == "" && !*collect {
+		fmt.Fprintln(os.Stderr, "usage: pgo [-collect] [-profiles a.pprof,b.pprof] [-bench regexp] [-count n] [-o default.pgo] package")
+		os.Exit(2)
+	}
+	pkg := flag.Arg(0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var log io.Writer
+	if *verbose {
+		log = os.Stderr
+	}
+
+	var inputs []string
+	if *profiles != "" {
+		inputs = strings.Split(*profiles, ",")
+	}
+	if *collect {
+		dir, err := os.MkdirTemp("", "pgo")
+		check(err)
+		defer os.RemoveAll(dir)
+		p := filepath.Join(dir, "collected.pprof")
+		fmt.Fprintf(os.Stderr, "collecting cpu profile from %s\n", pkg)
+		check(pgo.Collect(ctx, pkg, *bench, p, log))
+		inputs = append(inputs, p)
+	}
+	fmt.Fprintf(os.Stderr, "merging %d profiles into %s\n", len(inputs), *out)
+	check(pgo.Merge(ctx, *out, inputs...))
+
+	fmt.Fprintln(os.Stderr, "benchmarking with -pgo=off")
+	before, err := pgo.Bench(ctx, pkg, *bench, "off", *count, log)
+	check(err)
+	fmt.Fprintf(os.Stderr, "benchmarking with -pgo=%s\n", *out)
+	after, err := pgo.Bench(ctx, pkg, *bench, *out, *count, log)
+	check(err)
+	check(pgo.WriteReport(os.Stdout, pgo.Compare(before, after)))
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
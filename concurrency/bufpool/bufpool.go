@@ -0,0 +1,110 @@
+// Package bufpool 提供按尺寸分级的字节切片池。
+// syncpool_test.go 中的 bufferPool 只有一个池，处理 1KB 到 4MB 不等的请求时：
+// 池中的 Buffer 最终都会增长到见过的最大尺寸，1KB 的请求也占用 4MB（浪费内存）；
+// 如果丢弃大的 Buffer，大请求又总是取不到合适的对象（频繁未命中）。
+//
+// Pool 像 slab 分配器一样按 2 的幂划分尺寸等级，每个等级一个 sync.Pool。
+// Get(n) 从能容纳 n 的最小等级中取出切片，Put 按切片的容量放回对应等级，
+// 浪费的内存不超过一半，不同尺寸的请求也不会相互挤占。
+package bufpool
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool 是按尺寸分级的字节切片池，需要使用 New 创建
+type Pool struct {
+	minShift, maxShift int
+	classes            []class
+	// holders 复用存放切片的 *[]byte：直接把 []byte 放入 sync.Pool 需要装箱，每次 Put 都会分配内存
+	holders sync.Pool
+}
+
+type class struct {
+	pool      sync.Pool
+	gets, new atomic.Int64
+}
+
+// New 创建尺寸范围为 [min, max] 的 Pool，min 和 max 会向上取整为 2 的幂。
+// 超过 max 的请求每次直接分配，Put 时丢弃。
+func New(min, max int) *Pool {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	p := &Pool{minShift: shift(min), maxShift: shift(max)}
+	p.classes = make([]class, p.maxShift-p.minShift+1)
+	return p
+}
+
+// shift 返回不小于 n 的最小 2 的幂的指数
+func shift(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// Get 返回长度为 n 的切片，容量为能容纳 n 的最小等级尺寸，内容未清零
+func (p *Pool) Get(n int) []byte {
+	s := shift(n)
+	if s < p.minShift {
+		s = p.minShift
+	}
+	if s > p.maxShift {
+		return make([]byte, n)
+	}
+	c := &p.classes[s-p.minShift]
+	c.gets.Add(1)
+	if h, ok := c.pool.Get().(*[]byte); ok {
+		b := *h
+		*h = nil
+		p.holders.Put(h)
+		return b[:n]
+	}
+	c.new.Add(1)
+	return make([]byte, n, 1<<s)
+}
+
+// Put 把切片放回容量对应的等级：容量不是 2 的幂时放入比它小的等级，保证以后 Get 到的切片容量足够。
+// 容量小于最小等级或大于最大等级的切片被丢弃。Put 之后调用方不能再使用 b。
+func (p *Pool) Put(b []byte) {
+	c := cap(b)
+	if c == 0 {
+		return
+	}
+	s := bits.Len(uint(c)) - 1 // 向下取整
+	if s < p.minShift || s > p.maxShift {
+		return
+	}
+	h, _ := p.holders.Get().(*[]byte)
+	if h == nil {
+		h = new([]byte)
+	}
+	*h = b[:0]
+	p.classes[s-p.minShift].pool.Put(h)
+}
+
+// ClassStats 是一个尺寸等级的统计信息
+type ClassStats struct {
+	Size int   // 等级尺寸
+	Gets int64 // Get 的次数
+	News int64 // 未命中、新分配的次数
+}
+
+// Stats 返回每个等级的统计信息
+func (p *Pool) Stats() []ClassStats {
+	stats := make([]ClassStats, len(p.classes))
+	for i := range p.classes {
+		stats[i] = ClassStats{
+			Size: 1 << (p.minShift + i),
+			Gets: p.classes[i].gets.Load(),
+			News: p.classes[i].new.Load(),
+		}
+	}
+	return stats
+}
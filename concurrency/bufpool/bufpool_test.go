@@ -0,0 +1,51 @@
+package bufpool
+
+import (
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	p := New(1000, 4<<20)
+	if p.minShift != 10 || p.maxShift != 22 || len(p.classes) != 13 {
+		t.Fatalf("shifts %d..%d, %d classes", p.minShift, p.maxShift, len(p.classes))
+	}
+	for _, tc := range []struct{ n, cap int }{
+		{0, 1024}, {1, 1024}, {1024, 1024}, {1025, 2048}, {3000, 4096}, {4 << 20, 4 << 20}, {4<<20 + 1, 4<<20 + 1},
+	} {
+		b := p.Get(tc.n)
+		if len(b) != tc.n || cap(b) != tc.cap {
+			t.Errorf("Get(%d): len %d cap %d, want cap %d", tc.n, len(b), cap(b), tc.cap)
+		}
+		p.Put(b)
+	}
+}
+
+func TestPut(t *testing.T) {
+	p := New(1024, 8192)
+	// 容量 3000 向下归入 2048 等级，从 2048 等级取出时容量依然足够
+	for i := 0; i < 10; i++ {
+		p.Put(make([]byte, 10, 3000))
+		if b := p.Get(2048); cap(b) < 2048 {
+			t.Fatalf("cap %d < 2048", cap(b))
+		}
+	}
+	// 太小和太大的切片被丢弃
+	p.Put(make([]byte, 100))
+	p.Put(make([]byte, 1<<20))
+	for _, s := range p.Stats() {
+		if s.Size == 2048 && s.Gets != 10 {
+			t.Fatalf("unexpected stats %+v", s)
+		}
+	}
+}
+
+func TestPutAllocs(t *testing.T) {
+	p := New(1024, 1<<20)
+	p.Put(p.Get(4096))
+	allocs := testing.AllocsPerRun(100, func() {
+		p.Put(p.Get(4096))
+	})
+	if allocs > 0 {
+		t.Fatalf("Get/Put allocates %v times", allocs)
+	}
+}
@@ -0,0 +1,211 @@
+package concurrency
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"highPerformance/serialize/hpbin"
+)
+
+// 服务之间传输大量 Student 这样的记录时，JSON 的瓶颈不只在反射：
+// 字段名每条记录都要重复一遍，Remark [1024]byte 被编码成 1024 个十进制数字，即使全是 0 也有 2KB。
+// hpbin 按字段顺序编码，不写字段名，Remark 去掉末尾的 0 后按字节写入。
+
+// AppendBinary 追加 s 的 hpbin 编码，签名与 encoding.BinaryAppender 相同
+func (s *Student) AppendBinary(dst []byte) ([]byte, error) {
+	dst = hpbin.AppendString(dst, s.Name)
+	dst = hpbin.AppendVarint(dst, int64(s.Age))
+	return hpbin.AppendArray(dst, s.Remark[:]), nil
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler
+func (s *Student) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(make([]byte, 0, 16+len(s.Name)))
+}
+
+// UnmarshalBinary 实现 encoding.BinaryUnmarshaler。所有字段都会被覆盖，
+// 因此可以直接解码到 studentPool 中取出的、没有清空的对象；Name 与上一次相同时不分配内存
+func (s *Student) UnmarshalBinary(data []byte) error {
+	var r hpbin.Reader
+	r.Reset(data)
+	s.Name = r.String(s.Name)
+	s.Age = int32(r.Int(32))
+	r.Array(s.Remark[:])
+	return r.End()
+}
+
+// fixedStudent 是 encoding/binary 能够处理的形式：只接受定长的字段，
+// Name 只能放进定长数组，超出的部分被截断，即使是空字符串也要占满 32 字节
+type fixedStudent struct {
+	NameLen uint8
+	Name    [32]byte
+	Age     int32
+	Remark  [1024]byte
+}
+
+func toFixed(s *Student) *fixedStudent {
+	f := &fixedStudent{Age: s.Age, Remark: s.Remark}
+	f.NameLen = uint8(copy(f.Name[:], s.Name))
+	return f
+}
+
+func (f *fixedStudent) student(s *Student) {
+	s.Name = string(f.Name[:f.NameLen])
+	s.Age = f.Age
+	s.Remark = f.Remark
+}
+
+// codec 表示一个连接上的编码器和解码器。gob 在一条流上只发送一次类型信息，
+// 所以每个 codec 通过 new 创建有状态的 marshal 和 unmarshal，而不是每条记录单独编码
+type codec struct {
+	name string
+	new  func() (marshal func(dst []byte, s *Student) []byte, unmarshal func(data []byte, s *Student) error)
+}
+
+var codecs = []codec{
+	{"json", func() (func([]byte, *Student) []byte, func([]byte, *Student) error) {
+		return func(dst []byte, s *Student) []byte {
+				b, _ := json.Marshal((*plainStudent)(s))
+				return append(dst, b...)
+			}, func(data []byte, s *Student) error {
+				return json.Unmarshal(data, (*plainStudent)(s))
+			}
+	}},
+	{"hpgen", func() (func([]byte, *Student) []byte, func([]byte, *Student) error) {
+		return func(dst []byte, s *Student) []byte {
+				dst, _ = s.AppendJSON(dst)
+				return dst
+			}, func(data []byte, s *Student) error {
+				return s.UnmarshalJSON(data)
+			}
+	}},
+	{"gob", func() (func([]byte, *Student) []byte, func([]byte, *Student) error) {
+		var w, r bytes.Buffer
+		enc, dec := gob.NewEncoder(&w), gob.NewDecoder(&r)
+		return func(dst []byte, s *Student) []byte {
+				w.Reset()
+				enc.Encode((*plainStudent)(s))
+				return append(dst, w.Bytes()...)
+			}, func(data []byte, s *Student) error {
+				r.Write(data)
+				return dec.Decode((*plainStudent)(s))
+			}
+	}},
+	{"binary", func() (func([]byte, *Student) []byte, func([]byte, *Student) error) {
+		var w bytes.Buffer
+		var r bytes.Reader
+		return func(dst []byte, s *Student) []byte {
+				w.Reset()
+				binary.Write(&w, binary.LittleEndian, toFixed(s))
+				return append(dst, w.Bytes()...)
+			}, func(data []byte, s *Student) error {
+				var f fixedStudent
+				r.Reset(data)
+				if err := binary.Read(&r, binary.LittleEndian, &f); err != nil {
+					return err
+				}
+				f.student(s)
+				return nil
+			}
+	}},
+	{"hpbin", func() (func([]byte, *Student) []byte, func([]byte, *Student) error) {
+		return func(dst []byte, s *Student) []byte {
+				dst, _ = s.AppendBinary(dst)
+				return dst
+			}, func(data []byte, s *Student) error {
+				return s.UnmarshalBinary(data)
+			}
+	}},
+}
+
+// records 是三种典型的记录：Remark 为空、Remark 有一段简短的文字、Remark 被写满
+var records = func() []struct {
+	name string
+	stu  *Student
+} {
+	short := &Student{Name: "sungn", Age: 24}
+	remark := &Student{Name: "sungn", Age: 24}
+	copy(remark.Remark[:], "transferred from the east campus, scholarship 2023, contact via email only")
+	full := &Student{Name: "sungn", Age: -24}
+	copy(full.Remark[:], strings.Repeat("0123456789abcdef", 64))
+	return []struct {
+		name string
+		stu  *Student
+	}{{"empty", short}, {"remark", remark}, {"full", full}}
+}()
+
+func TestCodecs(t *testing.T) {
+	for _, c := range codecs {
+		marshal, unmarshal := c.new()
+		for _, rec := range records {
+			// 连续编解码两次，gob 的第二条消息不带类型信息
+			for i := 0; i < 2; i++ {
+				// 解码到上一条记录使用过的对象中
+				got := &Student{Name: "old", Age: 1}
+				copy(got.Remark[:], "stale")
+				if c.name == "gob" {
+					// gob 不传输零值的字段，解码后这些字段仍是旧的值，必须解码到清空的对象中
+					*got = Student{}
+				}
+				if err := unmarshal(marshal(nil, rec.stu), got); err != nil {
+					t.Fatalf("%s/%s: %v", c.name, rec.name, err)
+				}
+				if *got != *rec.stu {
+					t.Fatalf("%s/%s: got %q %d %q", c.name, rec.name, got.Name, got.Age, bytes.TrimRight(got.Remark[:], "\x00"))
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkCodecs 对每种格式和每种记录测量编码和解码的耗时，B/record 为一条记录编码后的大小。
+// 解码到 studentPool 中取出的、没有清空的对象：hpbin 覆盖所有字段，Name 与上一次相同时不分配内存；
+// gob 不覆盖零值字段，这里每次解码的是同一条记录所以结果正确，实际使用时应当换成 studentTypedPool。
+//
+//	go test -run xxx -bench Codecs -benchmem ./concurrency
+//
+// 单核环境下的一次结果，编码/解码的 ns/op，括号中为编码后的字节数：
+//
+//	        empty                 remark                full
+//	json    23500/73500 (2084)    23000/44500 (2205)    18200/84300 (3301)
+//	hpgen    6800/23200 (2084)     4400/15200 (2205)     5900/19000 (3301)
+//	gob      9900/13900 (1043)     9300/15700 (1043)    14100/19000 (1043)
+//	binary  10700/13700 (1061)    10700/13200 (1061)    10500/13100 (1061)
+//	hpbin     100/70    (8)          64/54    (82)         36/50    (1033)
+//
+// gob 和 encoding/binary 不论 Remark 的内容都写满 1KB，解码时还要分配 1KB 以上的临时空间；
+// hpbin 的编码和解码不分配内存，耗时主要是复制和清零 Remark，比 JSON 快两到三个数量级。
+func BenchmarkCodecs(b *testing.B) {
+	for _, c := range codecs {
+		for _, rec := range records {
+			b.Run(c.name+"/"+rec.name+"/encode", func(b *testing.B) {
+				marshal, _ := c.new()
+				dst := marshal(nil, rec.stu)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					dst = marshal(dst[:0], rec.stu)
+				}
+				b.ReportMetric(float64(len(dst)), "B/record")
+			})
+			b.Run(c.name+"/"+rec.name+"/decode", func(b *testing.B) {
+				marshal, unmarshal := c.new()
+				unmarshal(marshal(nil, rec.stu), new(Student))
+				data := marshal(nil, rec.stu)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					stu := studentPool.Get().(*Student)
+					if err := unmarshal(data, stu); err != nil {
+						b.Fatal(err)
+					}
+					studentPool.Put(stu)
+				}
+			})
+		}
+	}
+}
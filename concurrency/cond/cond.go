@@ -0,0 +1,85 @@
+// Package cond 提供可以被 context 取消的条件变量和事件。
+// synccond_test.go 中的 sync.Cond 无法设置等待超时，请求被取消后等待方只能一直阻塞；
+// 这里用 channel 实现通知，等待方可以在 ctx 取消或超时时放弃等待。
+package cond
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cond 与 sync.Cond 的用法相同：持有 L 时检查条件，不满足时调用 Wait。
+// 不同的是 Wait 接受 ctx，取消时返回 ctx.Err()。
+type Cond struct {
+	L sync.Locker
+
+	mu      sync.Mutex
+	waiters list.List // 元素为 chan struct{}，按等待顺序排列
+}
+
+// NewCond 创建使用 l 作为锁的 Cond
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait 释放 L 并等待 Signal 或 Broadcast，返回前重新获取 L。
+// 与 sync.Cond 一样，被唤醒时条件不一定成立，调用方需要在循环中重新检查；
+// ctx 取消时同样会在重新获取 L 后返回 ctx.Err()。
+func (c *Cond) Wait(ctx context.Context) error {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	elem := c.waiters.PushBack(ch)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	var err error
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		c.mu.Lock()
+		select {
+		case <-ch:
+			// 取消的同时被 Signal 选中，把这次通知转交给下一个等待者，避免通知丢失
+			c.signalLocked()
+		default:
+			c.waiters.Remove(elem)
+		}
+		c.mu.Unlock()
+		err = ctx.Err()
+	}
+	c.L.Lock()
+	return err
+}
+
+// WaitTimeout 与 Wait 相同，最多等待 d，被唤醒时返回 true，超时返回 false
+func (c *Cond) WaitTimeout(d time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return c.Wait(ctx) == nil
+}
+
+// Signal 唤醒等待时间最长的一个等待者
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	c.signalLocked()
+	c.mu.Unlock()
+}
+
+func (c *Cond) signalLocked() {
+	if front := c.waiters.Front(); front != nil {
+		c.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+	}
+}
+
+// Broadcast 唤醒所有等待者
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		close(e.Value.(chan struct{}))
+	}
+	c.waiters.Init()
+	c.mu.Unlock()
+}
@@ -0,0 +1,195 @@
+package cond
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 与 TestSyncCond 相同的场景，done 不再是包级全局变量
+func TestBroadcast(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	done := false
+	var woken atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			for !done {
+				if err := c.Wait(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}
+			mu.Unlock()
+			woken.Add(1)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	done = true
+	mu.Unlock()
+	c.Broadcast()
+	wg.Wait()
+	if woken.Load() != 3 {
+		t.Fatalf("woken %d, want 3", woken.Load())
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	mu.Lock()
+	if c.WaitTimeout(10 * time.Millisecond) {
+		t.Fatal("WaitTimeout returned true without signal")
+	}
+	// 超时返回时仍然持有锁
+	if mu.TryLock() {
+		t.Fatal("lock not held after WaitTimeout")
+	}
+	mu.Unlock()
+	c.mu.Lock()
+	n := c.waiters.Len()
+	c.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("%d waiters left after timeout", n)
+	}
+}
+
+func TestSignalOne(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	var woken atomic.Int32
+	for i := 0; i < 2; i++ {
+		go func() {
+			mu.Lock()
+			c.Wait(context.Background())
+			woken.Add(1)
+			mu.Unlock()
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.Signal()
+	time.Sleep(10 * time.Millisecond)
+	if n := woken.Load(); n != 1 {
+		t.Fatalf("woken %d, want 1", n)
+	}
+	c.Signal()
+}
+
+// 与 Signal 并发取消的等待者不能吞掉通知
+func TestCancelDoesNotLoseSignal(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		var mu sync.Mutex
+		c := NewCond(&mu)
+		ctx, cancel := context.WithCancel(context.Background())
+		woken := make(chan struct{})
+		mu.Lock()
+		go func() {
+			mu.Lock()
+			c.Wait(ctx)
+			mu.Unlock()
+		}()
+		go func() {
+			mu.Lock()
+			if c.Wait(context.Background()) == nil {
+				close(woken)
+			}
+			mu.Unlock()
+		}()
+		mu.Unlock()
+		for {
+			c.mu.Lock()
+			n := c.waiters.Len()
+			c.mu.Unlock()
+			if n == 2 {
+				break
+			}
+			time.Sleep(time.Microsecond)
+		}
+		go cancel()
+		c.Signal()
+		// 被取消的等待者要么没被选中，要么把通知转交给另一个，所以再 Signal 一次后另一个一定被唤醒
+		c.Signal()
+		select {
+		case <-woken:
+		case <-time.After(time.Second):
+			t.Fatal("signal lost")
+		}
+	}
+}
+
+func TestEvent(t *testing.T) {
+	e := NewEvent()
+	if e.WaitTimeout(time.Millisecond) {
+		t.Fatal("Wait returned before Set")
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		e.Set()
+	}()
+	if err := e.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !e.IsSet() || e.Generation() != 1 {
+		t.Fatalf("IsSet=%v Generation=%d", e.IsSet(), e.Generation())
+	}
+	e.Reset()
+	if e.IsSet() || e.WaitTimeout(time.Millisecond) {
+		t.Fatal("event still set after Reset")
+	}
+	// Set 后立即 Reset，按代数等待的一方不会错过
+	gen := e.Generation()
+	e.Set()
+	e.Reset()
+	if g, err := e.WaitGeneration(context.Background(), gen); err != nil || g != gen+1 {
+		t.Fatalf("got %d %v, want %d", g, err, gen+1)
+	}
+}
+
+// 两个协程通过条件变量轮流执行
+func pingPong(b *testing.B, wait func(), signal func(), mu *sync.Mutex) {
+	turn := 0
+	done := make(chan struct{})
+	go func() {
+		mu.Lock()
+		for i := 0; i < b.N; i++ {
+			for turn != 1 {
+				wait()
+			}
+			turn = 0
+			signal()
+		}
+		mu.Unlock()
+		close(done)
+	}()
+	mu.Lock()
+	for i := 0; i < b.N; i++ {
+		for turn != 0 {
+			wait()
+		}
+		turn = 1
+		signal()
+	}
+	mu.Unlock()
+	<-done
+}
+
+func BenchmarkSyncCond(b *testing.B) {
+	var mu sync.Mutex
+	c := sync.NewCond(&mu)
+	pingPong(b, c.Wait, c.Signal, &mu)
+}
+
+// 每次 Wait 都要创建一个 channel 并操作等待队列，开销高于基于运行时信号量的 sync.Cond，
+// 换来的是可以被取消的等待
+func BenchmarkCond(b *testing.B) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	ctx := context.Background()
+	pingPong(b, func() { c.Wait(ctx) }, c.Signal, &mu)
+}
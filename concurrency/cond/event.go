@@ -0,0 +1,96 @@
+package cond
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event 是可以重置的一次性事件，用来替代 synccond_test.go 中的全局变量 done：
+// Set 之后所有等待者被唤醒，之后的 Wait 立即返回，直到 Reset 开始新的一轮。
+// 每次 Set 使代数加一，等待方可以通过 WaitGeneration 等待某一代之后的事件，避免错过中间的 Set/Reset。
+type Event struct {
+	mu  sync.Mutex
+	ch  chan struct{}
+	set bool
+	gen uint64
+}
+
+// NewEvent 创建未触发的事件
+func NewEvent() *Event {
+	return &Event{ch: make(chan struct{})}
+}
+
+// Set 触发事件，唤醒所有等待者，重复调用无效
+func (e *Event) Set() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.set {
+		return
+	}
+	e.set = true
+	e.gen++
+	close(e.ch)
+}
+
+// Reset 将事件恢复为未触发状态
+func (e *Event) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.set {
+		e.set = false
+		e.ch = make(chan struct{})
+	}
+}
+
+// IsSet 报告事件当前是否处于触发状态
+func (e *Event) IsSet() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.set
+}
+
+// Generation 返回事件被触发的次数
+func (e *Event) Generation() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.gen
+}
+
+// Wait 等待事件触发，已触发时立即返回，ctx 取消时返回 ctx.Err()
+func (e *Event) Wait(ctx context.Context) error {
+	e.mu.Lock()
+	ch := e.ch
+	e.mu.Unlock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitTimeout 最多等待 d，事件触发时返回 true
+func (e *Event) WaitTimeout(d time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return e.Wait(ctx) == nil
+}
+
+// WaitGeneration 等待代数超过 gen，返回新的代数。
+// 即使事件在等待期间被触发后又被 Reset，也不会错过。
+func (e *Event) WaitGeneration(ctx context.Context, gen uint64) (uint64, error) {
+	for {
+		e.mu.Lock()
+		cur, ch := e.gen, e.ch
+		e.mu.Unlock()
+		if cur > gen {
+			return cur, nil
+		}
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return cur, ctx.Err()
+		}
+	}
+}
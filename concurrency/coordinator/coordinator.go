@@ -0,0 +1,124 @@
+// Package coordinator 实现 goroutine_test.go 中提到的情形三：M 个接收者和 N 个发送者，
+// 任何一个协程都可以请求停止，由调解者负责安全地关闭通道。
+//
+// 通道的关闭原则是只能由唯一的发送者关闭，有多个发送者时谁都不能直接关闭数据通道。
+// Coordinator 的做法是：
+//  1. 任意协程调用 RequestStop，停止信号通道只被关闭一次，并记录是谁发起的；
+//  2. 发送者在 Send 中观察到停止信号后返回 false 并退出；
+//  3. 调解协程等待所有发送者都退出后，才关闭数据通道，接收者的 range 随之结束。
+//
+// 调解协程在第一次 RequestStop 时才启动，从不停止的 Coordinator 不会留下阻塞的协程。
+package coordinator
+
+import (
+	"sync"
+)
+
+// Coordinator 协调多个发送者和接收者的停止，T 为数据的类型
+type Coordinator[T any] struct {
+	data    chan T
+	stop    chan struct{}
+	closed  chan struct{}
+	senders sync.WaitGroup
+
+	// mu 保护 stopped 和 initiator，同时保证 RequestStop 之后不会再有 senders.Add
+	mu        sync.Mutex
+	stopped   bool
+	initiator string
+}
+
+// New 创建数据通道缓冲区大小为 size 的 Coordinator
+func New[T any](size int) *Coordinator[T] {
+	return &Coordinator[T]{
+		data:   make(chan T, size),
+		stop:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// moderate 在停止信号发出后运行，等待所有发送者退出后关闭数据通道
+func (c *Coordinator[T]) moderate() {
+	c.senders.Wait()
+	close(c.data)
+	close(c.closed)
+}
+
+// Data 返回数据通道，所有发送者退出后关闭
+func (c *Coordinator[T]) Data() <-chan T { return c.data }
+
+// Stopping 返回停止信号通道，RequestStop 后关闭
+func (c *Coordinator[T]) Stopping() <-chan struct{} { return c.stop }
+
+// Closed 返回一个在数据通道关闭后关闭的 channel
+func (c *Coordinator[T]) Closed() <-chan struct{} { return c.closed }
+
+// RequestStop 请求停止，who 标识发起者，可以由发送者、接收者或任何其他协程调用，可以重复调用。
+// 只有第一次调用生效，返回 true 表示本次调用发起了停止。
+func (c *Coordinator[T]) RequestStop(who string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return false
+	}
+	c.stopped, c.initiator = true, who
+	close(c.stop)
+	go c.moderate()
+	return true
+}
+
+// Initiator 返回发起停止的协程，尚未停止时 ok 为 false
+func (c *Coordinator[T]) Initiator() (who string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.initiator, c.stopped
+}
+
+// Sender 是一个已登记的发送者
+type Sender[T any] struct {
+	c    *Coordinator[T]
+	name string
+	once sync.Once
+	live bool
+}
+
+// Sender 登记一个名为 name 的发送者，发送者退出前必须调用 Done，否则数据通道永远不会关闭。
+// 已经停止后登记的发送者不会被计数，其 Send 总是返回 false。
+func (c *Coordinator[T]) Sender(name string) *Sender[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &Sender[T]{c: c, name: name}
+	if !c.stopped {
+		c.senders.Add(1)
+		s.live = true
+	}
+	return s
+}
+
+// Send 发送 v，已经停止时返回 false，发送者应当随即退出
+func (s *Sender[T]) Send(v T) bool {
+	// 先单独检查一次停止信号：select 在多个分支同时就绪时随机选择，
+	// 数据通道有空位时仅靠下面的 select 可能在停止后仍然发送成功
+	select {
+	case <-s.c.stop:
+		return false
+	default:
+	}
+	select {
+	case <-s.c.stop:
+		return false
+	case s.c.data <- v:
+		return true
+	}
+}
+
+// Stop 以该发送者的名义请求停止
+func (s *Sender[T]) Stop() bool { return s.c.RequestStop(s.name) }
+
+// Done 表示发送者已经退出，可以重复调用
+func (s *Sender[T]) Done() {
+	s.once.Do(func() {
+		if s.live {
+			s.c.senders.Done()
+		}
+	})
+}
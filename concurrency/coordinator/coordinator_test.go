@@ -0,0 +1,112 @@
+package coordinator
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 10 个发送者、5 个接收者，其中一个接收者收到足够的数据后请求停止
+func TestReceiverStops(t *testing.T) {
+	c := New[int](10)
+	var sent, received atomic.Int64
+	for i := 0; i < 10; i++ {
+		s := c.Sender(fmt.Sprintf("sender#%d", i))
+		go func() {
+			defer s.Done()
+			for v := 0; s.Send(v); v++ {
+				sent.Add(1)
+			}
+		}()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for range c.Data() {
+				if received.Add(1) >= 1000 {
+					c.RequestStop(fmt.Sprintf("receiver#%d", i))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	<-c.Closed()
+	if sent.Load() != received.Load() {
+		t.Fatalf("sent %d, received %d", sent.Load(), received.Load())
+	}
+	who, ok := c.Initiator()
+	if !ok || !strings.HasPrefix(who, "receiver#") {
+		t.Fatalf("unexpected initiator %q %v", who, ok)
+	}
+}
+
+func TestSenderStops(t *testing.T) {
+	c := New[string](0)
+	for i := 0; i < 3; i++ {
+		s := c.Sender(fmt.Sprintf("sender#%d", i))
+		go func(i int) {
+			defer s.Done()
+			for n := 0; ; n++ {
+				if i == 1 && n == 100 {
+					s.Stop()
+					return
+				}
+				if !s.Send("x") {
+					return
+				}
+			}
+		}(i)
+	}
+	for range c.Data() {
+	}
+	if who, _ := c.Initiator(); who != "sender#1" {
+		t.Fatalf("got initiator %q, want sender#1", who)
+	}
+	if c.RequestStop("late") {
+		t.Fatal("second RequestStop should not initiate")
+	}
+}
+
+// 没有接收者时，阻塞在 Send 中的发送者也能因停止信号退出
+func TestStopUnblocksSenders(t *testing.T) {
+	c := New[int](0)
+	s := c.Sender("sender")
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		defer s.Done()
+		for s.Send(1) {
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.RequestStop("main")
+	select {
+	case <-c.Closed():
+	case <-time.After(time.Second):
+		t.Fatal("data channel not closed")
+	}
+	<-exited
+	if late := c.Sender("late"); late.Send(1) {
+		t.Fatal("late sender should not send after stop")
+	}
+}
+
+// 从不请求停止的 Coordinator 不应留下阻塞的调解协程
+func TestNoStopNoLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		c := New[int](1)
+		s := c.Sender("sender")
+		s.Send(i)
+		s.Done()
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines: %d before, %d after", before, n)
+	}
+}
@@ -0,0 +1,192 @@
+// Package hotconfig 提供可热更新的配置。
+// ReadConfig 用 sync.Once 读取一次 TT_SERVER_URL/TT_PORT 后就永远不变，修改配置必须重启服务。
+// Manager 把配置保存在 atomic.Pointer 中：读取方只做一次原子读，不加锁；
+// 收到 SIGHUP 或配置文件发生变化（轮询，不依赖外部的文件监听库）时重新加载，
+// 校验通过后原子替换，并通知订阅者。
+package hotconfig
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// snapshot 把配置和版本号放在同一个对象中原子替换，读取方不会看到新配置配旧版本号
+type snapshot[T any] struct {
+	cfg     *T
+	version uint64
+}
+
+// Manager 管理类型为 T 的配置
+type Manager[T any] struct {
+	cur      atomic.Pointer[snapshot[T]]
+	load     func() (*T, error)
+	validate func(*T) error
+
+	// reloadMu 保证加载、校验、替换和通知按顺序进行，订阅者看到的版本是递增的
+	reloadMu sync.Mutex
+	mu       sync.Mutex
+	subs     map[int]func(old, new *T)
+	onErr    func(error)
+	nextID   int
+}
+
+// New 创建 Manager 并立即加载一次配置，首次加载或校验失败时返回错误。
+// validate 可以为 nil。
+func New[T any](load func() (*T, error), validate func(*T) error) (*Manager[T], error) {
+	m := &Manager[T]{load: load, validate: validate, subs: make(map[int]func(old, new *T))}
+	c, err := m.loadValid()
+	if err != nil {
+		return nil, err
+	}
+	m.cur.Store(&snapshot[T]{cfg: c, version: 1})
+	return m, nil
+}
+
+// Get 返回当前配置，无锁。返回的配置不能被修改，新的配置总是以新对象替换旧对象。
+func (m *Manager[T]) Get() *T {
+	return m.cur.Load().cfg
+}
+
+// Version 返回配置的版本号，每次成功重新加载加一
+func (m *Manager[T]) Version() uint64 {
+	return m.cur.Load().version
+}
+
+// Snapshot 返回当前配置及其版本号，两者来自同一次原子读。
+// 分别调用 Get 和 Version 时，中间可能发生重新加载。
+func (m *Manager[T]) Snapshot() (*T, uint64) {
+	s := m.cur.Load()
+	return s.cfg, s.version
+}
+
+func (m *Manager[T]) loadValid() (*T, error) {
+	c, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	if m.validate != nil {
+		if err := m.validate(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Reload 重新加载配置，加载或校验失败时保留当前配置并返回错误
+func (m *Manager[T]) Reload() error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	c, err := m.loadValid()
+	if err != nil {
+		m.mu.Lock()
+		onErr := m.onErr
+		m.mu.Unlock()
+		if onErr != nil {
+			onErr(err)
+		}
+		return err
+	}
+	// reloadMu 保证只有一个协程在替换，读取旧版本号再存入新快照不会丢失更新
+	prev := m.cur.Load()
+	m.cur.Store(&snapshot[T]{cfg: c, version: prev.version + 1})
+	old := prev.cfg
+	m.mu.Lock()
+	subs := make([]func(old, new *T), 0, len(m.subs))
+	for _, fn := range m.subs {
+		subs = append(subs, fn)
+	}
+	m.mu.Unlock()
+	for _, fn := range subs {
+		fn(old, c)
+	}
+	return nil
+}
+
+// Subscribe 注册配置变更的回调，返回取消订阅的函数。
+// 回调在执行 Reload 的协程中同步调用，不应阻塞。
+func (m *Manager[T]) Subscribe(fn func(old, new *T)) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextID
+	m.nextID++
+	m.subs[id] = fn
+	return func() {
+		m.mu.Lock()
+		delete(m.subs, id)
+		m.mu.Unlock()
+	}
+}
+
+// OnError 设置后台重新加载失败时的回调，用于记录日志
+func (m *Manager[T]) OnError(fn func(error)) {
+	m.mu.Lock()
+	m.onErr = fn
+	m.mu.Unlock()
+}
+
+// WatchSignal 在收到 sigs 中的信号时重新加载，sigs 为空时使用 SIGHUP，ctx 取消后停止
+func (m *Manager[T]) WatchSignal(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				m.Reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// WatchFile 每隔 interval 检查一次 path 的修改时间和大小，发生变化时重新加载，ctx 取消后停止。
+// 文件暂时不存在（例如编辑器先删除再写入）时不会触发加载，等文件重新出现后再加载。
+func (m *Manager[T]) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	last, _ := os.Stat(path)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fi, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+					continue
+				}
+				last = fi
+				m.Reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// JSONFile 返回从 JSON 文件加载配置的函数，可以作为 New 的 load 参数
+func JSONFile[T any](path string) func() (*T, error) {
+	return func() (*T, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		c := new(T)
+		if err := json.Unmarshal(b, c); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+}
@@ -0,0 +1,181 @@
+package hotconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Config struct {
+	Server string `json:"server"`
+	Port   int64  `json:"port"`
+}
+
+func validate(c *Config) error {
+	if c.Port <= 0 || c.Port > 65535 {
+		return errors.New("invalid port")
+	}
+	return nil
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newManager(t *testing.T) (*Manager[Config], string) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, `{"server": "a.example.com", "port": 8080}`)
+	m, err := New(JSONFile[Config](path), validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, path
+}
+
+func TestReload(t *testing.T) {
+	m, path := newManager(t)
+	if c := m.Get(); c.Server != "a.example.com" || c.Port != 8080 || m.Version() != 1 {
+		t.Fatalf("unexpected initial config %+v version %d", c, m.Version())
+	}
+	var got []int64
+	cancel := m.Subscribe(func(old, new *Config) {
+		got = append(got, old.Port, new.Port)
+	})
+	writeConfig(t, path, `{"server": "b.example.com", "port": 9090}`)
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if c := m.Get(); c.Port != 9090 || m.Version() != 2 {
+		t.Fatalf("unexpected config %+v version %d", c, m.Version())
+	}
+	if len(got) != 2 || got[0] != 8080 || got[1] != 9090 {
+		t.Fatalf("unexpected notification %v", got)
+	}
+
+	// 校验失败时保留旧配置，也不通知订阅者
+	writeConfig(t, path, `{"server": "c.example.com", "port": 0}`)
+	if err := m.Reload(); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if c := m.Get(); c.Port != 9090 || len(got) != 2 {
+		t.Fatalf("invalid config was applied: %+v", c)
+	}
+	cancel()
+	writeConfig(t, path, `{"server": "d.example.com", "port": 1}`)
+	m.Reload()
+	if len(got) != 2 {
+		t.Fatal("cancelled subscriber was notified")
+	}
+}
+
+func waitVersion(t *testing.T, m *Manager[Config], v uint64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Version() < v {
+		if time.Now().After(deadline) {
+			t.Fatalf("version %d not reached, current %d", v, m.Version())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	m, path := newManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.WatchFile(ctx, path, 5*time.Millisecond)
+	writeConfig(t, path, `{"server": "b.example.com", "port": 9090}`)
+	// 内容长度相同时依赖修改时间，这里把修改时间调后以免落在同一时间粒度内
+	os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	waitVersion(t, m, 2)
+	if c := m.Get(); c.Server != "b.example.com" {
+		t.Fatalf("unexpected config %+v", c)
+	}
+}
+
+// 读取方只做原子读，与 Reload 并发时总能读到完整的某个版本
+func TestConcurrentGet(t *testing.T) {
+	m, path := newManager(t)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if c := m.Get(); validate(c) != nil {
+					t.Error("read invalid config")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		writeConfig(t, path, `{"server": "x", "port": 1234}`)
+		m.Reload()
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// 第 n 次加载的配置端口号为 n，Snapshot 读到的配置和版本号必须一一对应
+func TestSnapshotConsistent(t *testing.T) {
+	var n int64
+	m, err := New(func() (*Config, error) {
+		n++
+		return &Config{Port: n}, nil
+	}, validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if c, v := m.Snapshot(); uint64(c.Port) != v {
+					t.Errorf("config port %d with version %d", c.Port, v)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		m.Reload()
+	}
+	close(stop)
+	wg.Wait()
+	if c, v := m.Snapshot(); c.Port != 1001 || v != 1001 {
+		t.Fatalf("unexpected final config %+v version %d", c, v)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"server": "a", "port": 1}`), 0644)
+	m, _ := New(JSONFile[Config](path), nil)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = m.Get().Port
+		}
+	})
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package hotconfig
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestWatchSignal(t *testing.T) {
+	m, path := newManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.WatchSignal(ctx)
+	writeConfig(t, path, `{"server": "b.example.com", "port": 9090}`)
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	waitVersion(t, m, 2)
+}
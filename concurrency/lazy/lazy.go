@@ -0,0 +1,136 @@
+// Package lazy 提供可以返回错误、失败后可以重试的延迟初始化。
+// synconce_test.go 中的 ReadConfig 把 TT_PORT 的解析错误吞掉了，自定义的 Once 也无法报告失败：
+// 只要 f 执行过一次，无论成功与否都不会再执行。
+//
+// OnceErr 的规则是：初始化成功后结果永久缓存；失败时，这一次初始化期间所有并发的调用方都得到同一个错误，
+// 之后的调用重新初始化。热路径与 Once 一样只有一次原子读。
+package lazy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrGoexit 表示初始化函数调用了 runtime.Goexit（例如在测试中调用 t.FailNow），
+// 等待同一次初始化的其他调用方会得到该错误，之后的调用会重新初始化
+var ErrGoexit = errors.New("lazy: init called runtime.Goexit")
+
+// PanicError 表示初始化函数发生了 panic，等待同一次初始化的其他调用方会得到该错误
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("lazy: init panicked: %v", e.Value)
+}
+
+type call[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// OnceErr 延迟执行 f 并缓存成功的结果，零值不可用，需要使用 NewOnceErr 创建
+type OnceErr[T any] struct {
+	// val 放在第一个字段，原因与 sync.Once 把 done 放在第一个字段相同
+	val atomic.Pointer[T]
+	f   func() (T, error)
+
+	mu       sync.Mutex
+	inflight *call[T]
+}
+
+// NewOnceErr 创建以 f 初始化的 OnceErr
+func NewOnceErr[T any](f func() (T, error)) *OnceErr[T] {
+	return &OnceErr[T]{f: f}
+}
+
+// Get 返回初始化的结果，尚未成功初始化时执行 f。
+// 已有初始化在进行中时等待它的结果，而不是再执行一次 f。
+func (o *OnceErr[T]) Get() (T, error) {
+	if p := o.val.Load(); p != nil {
+		return *p, nil
+	}
+	return o.getSlow()
+}
+
+func (o *OnceErr[T]) getSlow() (T, error) {
+	o.mu.Lock()
+	if p := o.val.Load(); p != nil {
+		o.mu.Unlock()
+		return *p, nil
+	}
+	if c := o.inflight; c != nil {
+		o.mu.Unlock()
+		<-c.done
+		return c.val, c.err
+	}
+	c := &call[T]{done: make(chan struct{})}
+	o.inflight = c
+	o.mu.Unlock()
+
+	// 与 sync.OnceValue 相同，用 normalReturn 区分 f 正常返回和 runtime.Goexit：
+	// Goexit 时没有 panic，c.val 和 c.err 都还是零值，不能当作成功缓存下来
+	normalReturn := false
+	defer func() {
+		r := recover()
+		if r != nil {
+			c.err = &PanicError{Value: r}
+		} else if !normalReturn {
+			c.err = ErrGoexit
+		}
+		o.mu.Lock()
+		if c.err == nil {
+			v := c.val
+			o.val.Store(&v)
+		}
+		o.inflight = nil
+		o.mu.Unlock()
+		close(c.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+	c.val, c.err = o.f()
+	normalReturn = true
+	return c.val, c.err
+}
+
+// Done 报告是否已经成功初始化
+func (o *OnceErr[T]) Done() bool {
+	return o.val.Load() != nil
+}
+
+// Reset 丢弃已缓存的结果，下一次 Get 会重新初始化，主要用于测试。
+// 正在进行的初始化不受影响，其结果仍会被缓存。
+func (o *OnceErr[T]) Reset() {
+	o.mu.Lock()
+	o.val.Store(nil)
+	o.mu.Unlock()
+}
+
+// OnceValue 延迟执行不会失败的 f 并缓存结果。
+// f 发生 panic 时 panic 会传给执行 f 的调用方，并发等待的调用方得到 *PanicError，之后的调用会重试。
+// f 调用 runtime.Goexit 时同理，并发等待的调用方得到 ErrGoexit。
+type OnceValue[T any] struct {
+	once OnceErr[T]
+}
+
+// NewOnceValue 创建以 f 初始化的 OnceValue
+func NewOnceValue[T any](f func() T) *OnceValue[T] {
+	return &OnceValue[T]{once: OnceErr[T]{f: func() (T, error) { return f(), nil }}}
+}
+
+// Get 返回初始化的结果
+func (o *OnceValue[T]) Get() T {
+	v, err := o.once.Get()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Reset 丢弃已缓存的结果，主要用于测试
+func (o *OnceValue[T]) Reset() { o.once.Reset() }
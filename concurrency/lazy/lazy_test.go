@@ -0,0 +1,180 @@
+package lazy
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnceErrSuccess(t *testing.T) {
+	var calls atomic.Int32
+	o := NewOnceErr(func() (int, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return 42, nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := o.Get(); v != 42 || err != nil {
+				t.Errorf("got %d %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("f called %d times, want 1", calls.Load())
+	}
+	o.Reset()
+	o.Get()
+	if calls.Load() != 2 {
+		t.Fatalf("f called %d times after Reset, want 2", calls.Load())
+	}
+}
+
+// 一次失败的初始化期间，所有并发调用方得到同一个错误，下一次调用重试
+func TestOnceErrRetry(t *testing.T) {
+	errFirst := errors.New("first attempt failed")
+	var calls atomic.Int32
+	o := NewOnceErr(func() (string, error) {
+		if calls.Add(1) == 1 {
+			time.Sleep(10 * time.Millisecond)
+			return "", errFirst
+		}
+		return "ok", nil
+	})
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := o.Get(); err == errFirst {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 || failed.Load() != 10 {
+		t.Fatalf("calls=%d failed=%d, want 1 and 10", calls.Load(), failed.Load())
+	}
+	if v, err := o.Get(); v != "ok" || err != nil {
+		t.Fatalf("retry got %q %v", v, err)
+	}
+	if !o.Done() {
+		t.Fatal("Done() = false after success")
+	}
+}
+
+// f 调用 runtime.Goexit 时不能把零值当作成功的结果缓存
+func TestOnceErrGoexit(t *testing.T) {
+	var calls atomic.Int32
+	o := NewOnceErr(func() (int, error) {
+		if calls.Add(1) == 1 {
+			runtime.Goexit()
+		}
+		return 42, nil
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.Get()
+	}()
+	<-done
+	if o.Done() {
+		t.Fatal("Goexit cached as a successful result")
+	}
+	if v, err := o.Get(); v != 42 || err != nil || calls.Load() != 2 {
+		t.Fatalf("got %d %v after %d calls, want retry", v, err, calls.Load())
+	}
+}
+
+func TestOnceValuePanic(t *testing.T) {
+	var calls atomic.Int32
+	o := NewOnceValue(func() int {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		return 7
+	})
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("recovered %v, want boom", r)
+			}
+		}()
+		o.Get()
+	}()
+	if v := o.Get(); v != 7 {
+		t.Fatalf("got %d, want 7", v)
+	}
+}
+
+// once 是 synconce_test.go 中原子读 done 的 Once 实现
+type once struct {
+	done uint32
+	m    sync.Mutex
+}
+
+func (o *once) Do(f func()) {
+	if atomic.LoadUint32(&o.done) == 0 {
+		o.doSlow(f)
+	}
+}
+
+func (o *once) doSlow(f func()) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	if o.done == 0 {
+		defer atomic.StoreUint32(&o.done, 1)
+		f()
+	}
+}
+
+var sink int
+
+// 初始化完成后的热路径都只有一次原子读、不加锁。
+// OnceErr.Get 约 4~5.5ns，Once 约 2.2~2.7ns，大约是 2 倍：
+// Get 按 go.shape 实例化后内联代价为 95，超过 80 的预算（-gcflags=-m=2 可见），
+// 每次都是一次带字典参数的真实调用，而 Once.Do 被内联成了一次原子读。
+// 即便如此仍远小于一次加锁的开销
+func BenchmarkOnce(b *testing.B) {
+	var o once
+	var v int
+	for i := 0; i < b.N; i++ {
+		o.Do(func() { v = 1 })
+		sink = v
+	}
+}
+
+func BenchmarkSyncOnce(b *testing.B) {
+	var o sync.Once
+	var v int
+	for i := 0; i < b.N; i++ {
+		o.Do(func() { v = 1 })
+		sink = v
+	}
+}
+
+func BenchmarkOnceErr(b *testing.B) {
+	o := NewOnceErr(func() (int, error) { return 1, nil })
+	for i := 0; i < b.N; i++ {
+		sink, _ = o.Get()
+	}
+}
+
+func BenchmarkOnceErrParallel(b *testing.B) {
+	o := NewOnceErr(func() (int, error) { return 1, nil })
+	b.RunParallel(func(pb *testing.PB) {
+		var v int
+		for pb.Next() {
+			v, _ = o.Get()
+		}
+		_ = v
+	})
+}
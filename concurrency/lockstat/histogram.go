@@ -0,0 +1,126 @@
+package lockstat
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 直方图按 2 的幂划分桶：第 i 个桶统计 [2^i, 2^(i+1)) 纳秒内的样本，第 0 个桶同时统计 0ns。
+// 记录只需要几次原子加法，不加锁，也不分配内存。
+const buckets = 64
+
+// Histogram 是耗时分布的快照
+type Histogram struct {
+	Buckets [buckets]int64
+	Count   int64
+	Sum     time.Duration
+	Max     time.Duration
+}
+
+type histogram struct {
+	buckets [buckets]atomic.Int64
+	count   atomic.Int64
+	sum     atomic.Int64
+	max     atomic.Int64
+}
+
+func bucketOf(d time.Duration) int {
+	i := 0
+	for n := uint64(d); n > 1; n >>= 1 {
+		i++
+	}
+	return i
+}
+
+func (h *histogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.buckets[bucketOf(d)].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+	for {
+		m := h.max.Load()
+		if int64(d) <= m || h.max.CompareAndSwap(m, int64(d)) {
+			return
+		}
+	}
+}
+
+func (h *histogram) snapshot() Histogram {
+	var s Histogram
+	for i := range h.buckets {
+		s.Buckets[i] = h.buckets[i].Load()
+	}
+	s.Count = h.count.Load()
+	s.Sum = time.Duration(h.sum.Load())
+	s.Max = time.Duration(h.max.Load())
+	return s
+}
+
+// Mean 返回平均耗时
+func (h *Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Percentile 返回第 p 百分位（0~100）所在桶的上界，精度为 2 倍以内，且不超过 Max
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	target := int64(p / 100 * float64(h.Count))
+	if target >= h.Count {
+		target = h.Count - 1
+	}
+	var n int64
+	for i, c := range h.Buckets {
+		n += c
+		if n > target {
+			upper := time.Duration(1) << (i + 1)
+			if upper > h.Max {
+				return h.Max
+			}
+			return upper
+		}
+	}
+	return h.Max
+}
+
+// WriteTo 输出非空的桶和对应的条形图
+func (h *Histogram) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	var most int64
+	lo, hi := -1, 0
+	for i, c := range h.Buckets {
+		if c > 0 {
+			if lo < 0 {
+				lo = i
+			}
+			hi = i
+		}
+		if c > most {
+			most = c
+		}
+	}
+	fmt.Fprintf(&b, "count=%d mean=%v p50=%v p99=%v p999=%v max=%v\n",
+		h.Count, h.Mean(), h.Percentile(50), h.Percentile(99), h.Percentile(99.9), h.Max)
+	for i := lo; lo >= 0 && i <= hi; i++ {
+		c := h.Buckets[i]
+		fmt.Fprintf(&b, "  < %-10v %8d %s\n", time.Duration(1)<<(i+1), c,
+			strings.Repeat("#", int((c*40+most-1)/most)))
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (h *Histogram) String() string {
+	var b strings.Builder
+	h.WriteTo(&b)
+	return b.String()
+}
@@ -0,0 +1,235 @@
+// Package lockstat 提供带统计信息的 Mutex 和 RWMutex，可以直接替换 sync.Mutex 和 sync.RWMutex。
+// mutex_test.go 中描述了互斥锁的正常模式和饥饿模式：等待超过 1ms 的协程会把锁切换到饥饿模式。
+// 只看 sync.Mutex 是观察不到这些现象的，替换成 lockstat.Mutex 后可以看到：
+//   - 获取锁的等待时间分布，等待时间的长尾正是切换到饥饿模式的原因
+//   - 持有锁的时间分布
+//   - 发生争用（没能立即获取到锁）的次数
+//   - 持有锁时间最长的调用位置
+//
+// 每次加锁和解锁额外有三次 time.Now 和一次 runtime.Callers，在本机测得约 500ns（sync.Mutex 约 20ns），只适合用于诊断。
+package lockstat
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats 是锁的统计信息快照
+type Stats struct {
+	Acquired  int64 // 获取锁的次数
+	Contended int64 // 没能立即获取锁的次数
+	Wait      Histogram
+	Hold      Histogram
+	// MaxHoldSite 是持有锁时间最长的那次加锁的调用位置，形如 "pkg.Func file:line"
+	MaxHoldSite string
+
+	// 以下仅 RWMutex 有效
+	ReadAcquired  int64
+	ReadContended int64
+	ReadWait      Histogram
+	// ReadHold 是锁处于读锁定状态的时长分布：从第一个读者进入到最后一个读者离开，
+	// 多个读者的持有时间相互重叠，无法在不改变 RUnlock 签名的情况下单独统计每个读者。
+	ReadHold Histogram
+}
+
+// stats 是 Mutex 和 RWMutex 共用的写锁统计
+type stats struct {
+	acquired  atomic.Int64
+	contended atomic.Int64
+	wait      histogram
+	hold      histogram
+
+	// 以下字段只在持有锁时读写
+	since time.Time
+	pc    uintptr
+
+	maxMu   sync.Mutex
+	maxHold time.Duration
+	maxPC   uintptr
+}
+
+func (s *stats) onAcquire(start time.Time, contended bool) {
+	now := time.Now()
+	s.acquired.Add(1)
+	if contended {
+		s.contended.Add(1)
+	}
+	s.wait.record(now.Sub(start))
+	var pcs [1]uintptr
+	// 跳过 runtime.Callers、onAcquire 和 Lock
+	runtime.Callers(3, pcs[:])
+	s.since, s.pc = now, pcs[0]
+}
+
+func (s *stats) onRelease() {
+	d := time.Since(s.since)
+	pc := s.pc
+	s.hold.record(d)
+	s.maxMu.Lock()
+	if d > s.maxHold {
+		s.maxHold, s.maxPC = d, pc
+	}
+	s.maxMu.Unlock()
+}
+
+func (s *stats) snapshot() Stats {
+	st := Stats{
+		Acquired:  s.acquired.Load(),
+		Contended: s.contended.Load(),
+		Wait:      s.wait.snapshot(),
+		Hold:      s.hold.snapshot(),
+	}
+	s.maxMu.Lock()
+	pc := s.maxPC
+	s.maxMu.Unlock()
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		st.MaxHoldSite = fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line)
+	}
+	return st
+}
+
+// Mutex 是带统计信息的互斥锁，零值可用
+type Mutex struct {
+	mu sync.Mutex
+	s  stats
+}
+
+func (m *Mutex) Lock() {
+	start := time.Now()
+	contended := !m.mu.TryLock()
+	if contended {
+		m.mu.Lock()
+	}
+	m.s.onAcquire(start, contended)
+}
+
+func (m *Mutex) TryLock() bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	m.s.onAcquire(time.Now(), false)
+	return true
+}
+
+func (m *Mutex) Unlock() {
+	// 必须在真正释放锁之前统计，释放之后 since 和 pc 可能已被下一个持有者改写
+	m.s.onRelease()
+	m.mu.Unlock()
+}
+
+// Stats 返回统计信息快照
+func (m *Mutex) Stats() Stats {
+	return m.s.snapshot()
+}
+
+// RWMutex 是带统计信息的读写锁，零值可用
+type RWMutex struct {
+	mu sync.RWMutex
+	s  stats
+
+	readAcquired  atomic.Int64
+	readContended atomic.Int64
+	readWait      histogram
+	readHold      histogram
+	readers       atomic.Int32
+	readSince     atomic.Int64
+}
+
+func (m *RWMutex) Lock() {
+	start := time.Now()
+	contended := !m.mu.TryLock()
+	if contended {
+		m.mu.Lock()
+	}
+	m.s.onAcquire(start, contended)
+}
+
+func (m *RWMutex) Unlock() {
+	m.s.onRelease()
+	m.mu.Unlock()
+}
+
+func (m *RWMutex) RLock() {
+	start := time.Now()
+	contended := !m.mu.TryRLock()
+	if contended {
+		m.mu.RLock()
+	}
+	now := time.Now()
+	m.readAcquired.Add(1)
+	if contended {
+		m.readContended.Add(1)
+	}
+	m.readWait.record(now.Sub(start))
+	if m.readers.Add(1) == 1 {
+		m.readSince.Store(now.UnixNano())
+	}
+}
+
+func (m *RWMutex) RUnlock() {
+	if m.readers.Add(-1) == 0 {
+		m.readHold.record(time.Duration(time.Now().UnixNano() - m.readSince.Load()))
+	}
+	m.mu.RUnlock()
+}
+
+// RLocker 返回以 RLock 和 RUnlock 实现 sync.Locker 接口的对象
+func (m *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(m)
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock()   { (*RWMutex)(r).RLock() }
+func (r *rlocker) Unlock() { (*RWMutex)(r).RUnlock() }
+
+// Stats 返回统计信息快照
+func (m *RWMutex) Stats() Stats {
+	st := m.s.snapshot()
+	st.ReadAcquired = m.readAcquired.Load()
+	st.ReadContended = m.readContended.Load()
+	st.ReadWait = m.readWait.snapshot()
+	st.ReadHold = m.readHold.snapshot()
+	return st
+}
+
+// WriteTo 输出文本报告
+func (s *Stats) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "acquired %d, contended %d (%.1f%%)\n", s.Acquired, s.Contended, percent(s.Contended, s.Acquired))
+	if s.MaxHoldSite != "" {
+		fmt.Fprintf(&b, "longest hold %v at %s\n", s.Hold.Max, s.MaxHoldSite)
+	}
+	b.WriteString("wait: ")
+	s.Wait.WriteTo(&b)
+	b.WriteString("hold: ")
+	s.Hold.WriteTo(&b)
+	if s.ReadAcquired > 0 {
+		fmt.Fprintf(&b, "read acquired %d, contended %d (%.1f%%)\n", s.ReadAcquired, s.ReadContended, percent(s.ReadContended, s.ReadAcquired))
+		b.WriteString("read wait: ")
+		s.ReadWait.WriteTo(&b)
+		b.WriteString("read held: ")
+		s.ReadHold.WriteTo(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (s *Stats) String() string {
+	var b strings.Builder
+	s.WriteTo(&b)
+	return b.String()
+}
+
+func percent(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
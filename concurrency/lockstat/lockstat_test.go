@@ -0,0 +1,114 @@
+package lockstat
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for i := 0; i < 99; i++ {
+		h.record(100 * time.Nanosecond)
+	}
+	h.record(time.Millisecond)
+	s := h.snapshot()
+	if s.Count != 100 || s.Max != time.Millisecond {
+		t.Fatalf("count %d max %v", s.Count, s.Max)
+	}
+	// 100ns 落在 [64ns, 128ns) 的桶中
+	if p := s.Percentile(50); p != 128*time.Nanosecond {
+		t.Fatalf("p50 = %v", p)
+	}
+	if p := s.Percentile(99.9); p != time.Millisecond {
+		t.Fatalf("p999 = %v", p)
+	}
+	if mean := s.Mean(); mean != (99*100*time.Nanosecond+time.Millisecond)/100 {
+		t.Fatalf("mean = %v", mean)
+	}
+}
+
+func longHold(m *Mutex) {
+	m.Lock()
+	time.Sleep(20 * time.Millisecond)
+	m.Unlock()
+}
+
+func TestMutex(t *testing.T) {
+	var m Mutex
+	var wg sync.WaitGroup
+	count := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				m.Lock()
+				count++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	longHold(&m)
+	// 长时间持有锁的同时有协程等待，必然发生争用
+	done := make(chan struct{})
+	go func() {
+		longHold(&m)
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	m.Lock()
+	m.Unlock()
+	<-done
+
+	s := m.Stats()
+	if count != 1000 || s.Acquired != 1003 {
+		t.Fatalf("count %d acquired %d", count, s.Acquired)
+	}
+	if s.Contended == 0 || s.Wait.Max < 5*time.Millisecond {
+		t.Fatalf("contention not observed: %v", s.String())
+	}
+	if s.Hold.Max < 20*time.Millisecond || !strings.Contains(s.MaxHoldSite, "lockstat.longHold") {
+		t.Fatalf("unexpected longest holder %v at %q", s.Hold.Max, s.MaxHoldSite)
+	}
+	t.Log("\n" + s.String())
+}
+
+func TestRWMutex(t *testing.T) {
+	var m RWMutex
+	m.RLock()
+	m.RLock()
+	time.Sleep(10 * time.Millisecond)
+	m.RUnlock()
+	m.RUnlock()
+	m.Lock()
+	if m.mu.TryRLock() {
+		t.Fatal("read lock acquired while write locked")
+	}
+	m.Unlock()
+	m.Lock()
+	m.Unlock()
+	s := m.Stats()
+	if s.ReadAcquired != 2 || s.ReadHold.Count != 1 || s.ReadHold.Max < 10*time.Millisecond || s.Acquired != 2 || s.Hold.Count != 2 {
+		t.Fatalf("unexpected stats:\n%v", s.String())
+	}
+}
+
+func BenchmarkMutex(b *testing.B) {
+	b.Run("sync", func(b *testing.B) {
+		var m sync.Mutex
+		for i := 0; i < b.N; i++ {
+			m.Lock()
+			m.Unlock()
+		}
+	})
+	b.Run("lockstat", func(b *testing.B) {
+		var m Mutex
+		for i := 0; i < b.N; i++ {
+			m.Lock()
+			m.Unlock()
+		}
+	})
+}
@@ -3,7 +3,6 @@ package concurrency
 import (
 	"sync"
 	"testing"
-	"time"
 )
 
 // Go 语言标准库 sync 提供了 2 种锁，互斥锁(sync.Mutex)和读写锁(sync.RWMutex)
@@ -14,73 +13,123 @@ type RW interface {
 	Read()
 }
 
-const cost = time.Nanosecond * 10
+// 各个实现的 work 字段是临界区内忙等的迭代次数，defaultWork 是最初几个 benchmark 使用的值。
+// 之前用 time.Sleep(10ns) 模拟临界区，但 Sleep 会让出 CPU 并至少睡眠一个调度周期（微秒级），
+// 测出来的主要是调度器的开销而不是锁的开销，所以改为纯计算的忙等。
+// work 放在锁自身的字段中而不是包级变量里，不同的 benchmark 并行运行时不会相互影响。
+const defaultWork = 50
+
+// busy 模拟临界区内的计算，noinline 防止编译器把没有使用返回值的循环优化掉
+//
+//go:noinline
+func busy(n int) int {
+	x := 0
+	for i := 0; i < n; i++ {
+		x = x*31 + i
+	}
+	return x
+}
 
 type Lock struct {
 	count int
+	work  int
 	mu    sync.Mutex
 }
 
 func (l *Lock) Write() {
 	l.mu.Lock()
 	l.count++
-	time.Sleep(cost)
+	busy(l.work)
 	l.mu.Unlock()
 }
 
 func (l *Lock) Read() {
 	l.mu.Lock()
 	_ = l.count
-	time.Sleep(cost)
+	busy(l.work)
 	l.mu.Unlock()
 }
 
 type RWLock struct {
 	count int
+	work  int
 	mu    sync.RWMutex
 }
 
 func (l *RWLock) Write() {
 	l.mu.Lock()
 	l.count++
-	time.Sleep(cost)
+	busy(l.work)
 	l.mu.Unlock()
 }
 
 func (l *RWLock) Read() {
 	l.mu.RLock()
 	_ = l.count
-	time.Sleep(cost)
+	busy(l.work)
 	l.mu.RUnlock()
 }
 
-func benchmark(b *testing.B, rw RW, read, write int) {
-	for i := 0; i < b.N; i++ {
-		var wg sync.WaitGroup
-		for k := 0; k < read*100; k++ {
-			wg.Add(1)
-			go func() {
-				rw.Read()
-				wg.Done()
-			}()
-		}
-		for k := 0; k < write*100; k++ {
-			wg.Add(1)
-			go func() {
-				rw.Write()
-				wg.Done()
-			}()
-		}
-		wg.Wait()
+// workload 描述一种访问模式，临界区的开销由各个实现的 work 字段决定
+type workload struct {
+	readPct    int // 读操作所占的百分比
+	goroutines int // 并发的协程数
+}
+
+// shardReader 由需要区分读者的实现提供（如 ShardedRW），run 为每个协程传入固定的编号，
+// 读者据此选择分片，不需要通过共享的计数器分配
+type shardReader interface {
+	ReadShard(id int)
+}
+
+// benchmark 启动 w.goroutines 个协程，共执行 b.N 次操作，按 w.readPct 的比例混合读写，
+// ns/op 即每次读或写的平均耗时。协程数固定，不再像最初那样每次操作创建一个协程，
+// 否则协程的创建和调度开销会淹没锁本身的差异。
+func benchmark(b *testing.B, rw RW, w workload) {
+	b.ResetTimer()
+	run(rw, w, b.N)
+}
+
+// run 以 w 描述的访问模式共执行 ops 次操作
+func run(rw RW, w workload, ops int) {
+	var wg sync.WaitGroup
+	per := ops/w.goroutines + 1
+	for g := 0; g < w.goroutines; g++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			read := rw.Read
+			if s, ok := rw.(shardReader); ok {
+				read = func() { s.ReadShard(id) }
+			}
+			// 每个协程各自的 xorshift 随机数，避免共享随机源成为瓶颈
+			x := uint32(id)*2654435761 + 1
+			for i := 0; i < per; i++ {
+				x ^= x << 13
+				x ^= x >> 17
+				x ^= x << 5
+				if int(x%100) < w.readPct {
+					read()
+				} else {
+					rw.Write()
+				}
+			}
+		}(g)
 	}
+	wg.Wait()
+}
+
+// legacy 对应最初的读写比例，100 个协程
+func legacy(read, write int) workload {
+	return workload{readPct: read * 100 / (read + write), goroutines: 100}
 }
 
-func BenchmarkReadMore(b *testing.B)    { benchmark(b, &Lock{}, 9, 1) }
-func BenchmarkReadMoreRW(b *testing.B)  { benchmark(b, &RWLock{}, 9, 1) }
-func BenchmarkWriteMore(b *testing.B)   { benchmark(b, &Lock{}, 1, 9) }
-func BenchmarkWriteMoreRW(b *testing.B) { benchmark(b, &RWLock{}, 1, 9) }
-func BenchmarkEqual(b *testing.B)       { benchmark(b, &Lock{}, 5, 5) }
-func BenchmarkEqualRW(b *testing.B)     { benchmark(b, &RWLock{}, 5, 5) }
+func BenchmarkReadMore(b *testing.B)    { benchmark(b, &Lock{work: defaultWork}, legacy(9, 1)) }
+func BenchmarkReadMoreRW(b *testing.B)  { benchmark(b, &RWLock{work: defaultWork}, legacy(9, 1)) }
+func BenchmarkWriteMore(b *testing.B)   { benchmark(b, &Lock{work: defaultWork}, legacy(1, 9)) }
+func BenchmarkWriteMoreRW(b *testing.B) { benchmark(b, &RWLock{work: defaultWork}, legacy(1, 9)) }
+func BenchmarkEqual(b *testing.B)       { benchmark(b, &Lock{work: defaultWork}, legacy(5, 5)) }
+func BenchmarkEqualRW(b *testing.B)     { benchmark(b, &RWLock{work: defaultWork}, legacy(5, 5)) }
 
 // 互斥锁有两种状态：正常状态和饥饿状态。
 // 在正常状态下，所有等待锁的 goroutine 按照FIFO顺序等待。唤醒的 goroutine 不会直接拥有锁，而是会和新请求锁的 goroutine 竞争锁的拥有
@@ -89,3 +138,4 @@ func BenchmarkEqualRW(b *testing.B)     { benchmark(b, &RWLock{}, 5, 5) }
 // 在饥饿模式下，锁的所有权将从 unlock 的 goroutine 直接交给交给等待队列中的第一个。新来的 goroutine 将不会尝试去获得锁，即使锁看起来是 unlock 状态, 也不会去尝试自旋操作，而是放在等待队列的尾部。
 // 如果一个等待的 goroutine 获取了锁，并且满足一以下其中的任何一个条件：(1)它是队列中的最后一个；(2)它等待的时候小于1ms。它会将锁的状态转换为正常状态。
 // 正常状态有很好的性能表现，饥饿模式也是非常重要的，因为它能阻止尾部延迟的现象。
+// starvation_test.go 中的 TestStarvation 用实验数据展示了这一点。
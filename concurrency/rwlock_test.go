@@ -0,0 +1,311 @@
+package concurrency
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 除了 Lock 和 RWLock，RW 接口还有以下几种常见实现，适用的访问模式各不相同：
+//   AtomicRW   没有锁，计数器本身就是原子变量，只适用于状态能用单个原子变量表示的场景
+//   ShardedRW  分片读写锁，读者只锁自己的分片，写者要锁住全部分片，读多写极少时读者之间没有缓存行争用
+//              （读者的分片由 run 传入的协程编号决定，见 shardReader）
+//   SpinLock   自旋锁，拿不到锁时 Gosched 让出 CPU，临界区极短时省去了协程挂起和唤醒的开销
+//   SeqLock    顺序锁，读者不加锁，读完检查版本号，有写入发生则重试，写多时读者会反复重试
+//   CowRW      写时复制，读者原子加载指针后读取不可变的快照，写者复制整个状态后替换指针
+
+type AtomicRW struct {
+	count atomic.Int64
+	work  int
+}
+
+func (l *AtomicRW) Write() {
+	l.count.Add(1)
+	busy(l.work)
+}
+
+func (l *AtomicRW) Read() {
+	_ = l.count.Load()
+	busy(l.work)
+}
+
+// paddedRWMutex 独占一个缓存行，避免相邻分片之间的伪共享
+type paddedRWMutex struct {
+	sync.RWMutex
+	_ [64 - 24]byte
+}
+
+const shards = 16
+
+type ShardedRW struct {
+	count int
+	work  int
+	mu    [shards]paddedRWMutex
+}
+
+func (l *ShardedRW) Write() {
+	for i := range l.mu {
+		l.mu[i].Lock()
+	}
+	l.count++
+	busy(l.work)
+	for i := range l.mu {
+		l.mu[i].Unlock()
+	}
+}
+
+// Read 没有读者编号，使用 0 号分片；run 会改为调用 ReadShard
+func (l *ShardedRW) Read() {
+	l.ReadShard(0)
+}
+
+// ReadShard 锁住读者 id 对应的分片。分片由调用方固定的编号决定，
+// 不能用共享的原子计数器轮转：每个读者都要写同一个缓存行，又会出现分片想要消除的争用
+func (l *ShardedRW) ReadShard(id int) {
+	mu := &l.mu[id%shards]
+	mu.RLock()
+	_ = l.count
+	busy(l.work)
+	mu.RUnlock()
+}
+
+type SpinLock struct {
+	count int
+	work  int
+	state atomic.Int32
+}
+
+func (l *SpinLock) lock() {
+	for !l.state.CompareAndSwap(0, 1) {
+		runtime.Gosched()
+	}
+}
+
+func (l *SpinLock) unlock() {
+	l.state.Store(0)
+}
+
+func (l *SpinLock) Write() {
+	l.lock()
+	l.count++
+	busy(l.work)
+	l.unlock()
+}
+
+func (l *SpinLock) Read() {
+	l.lock()
+	_ = l.count
+	busy(l.work)
+	l.unlock()
+}
+
+type SeqLock struct {
+	// 读者不加锁，受保护的数据也必须用原子操作访问，否则存在数据竞争
+	count atomic.Int64
+	seq   atomic.Uint64
+	work  int
+	mu    sync.Mutex
+}
+
+func (l *SeqLock) Write() {
+	l.mu.Lock()
+	l.seq.Add(1) // 奇数表示正在写
+	l.count.Add(1)
+	busy(l.work)
+	l.seq.Add(1)
+	l.mu.Unlock()
+}
+
+func (l *SeqLock) Read() {
+	for {
+		s := l.seq.Load()
+		if s&1 == 1 {
+			runtime.Gosched()
+			continue
+		}
+		_ = l.count.Load()
+		busy(l.work)
+		if l.seq.Load() == s {
+			return
+		}
+	}
+}
+
+type cowState struct {
+	count int
+	data  [8]int
+}
+
+type CowRW struct {
+	p    atomic.Pointer[cowState]
+	work int
+	mu   sync.Mutex
+}
+
+func (l *CowRW) Write() {
+	l.mu.Lock()
+	next := new(cowState)
+	if old := l.p.Load(); old != nil {
+		*next = *old
+	}
+	next.count++
+	busy(l.work)
+	l.p.Store(next)
+	l.mu.Unlock()
+}
+
+func (l *CowRW) Read() {
+	if s := l.p.Load(); s != nil {
+		_ = s.count
+	}
+	busy(l.work)
+}
+
+type lockImpl struct {
+	name string
+	new  func(work int) RW // work 为临界区忙等的迭代次数
+}
+
+var lockImpls = []lockImpl{
+	{"Mutex", func(work int) RW { return &Lock{work: work} }},
+	{"RWMutex", func(work int) RW { return &RWLock{work: work} }},
+	{"Atomic", func(work int) RW { return &AtomicRW{work: work} }},
+	{"Sharded", func(work int) RW { return &ShardedRW{work: work} }},
+	{"Spin", func(work int) RW { return &SpinLock{work: work} }},
+	{"Seq", func(work int) RW { return &SeqLock{work: work} }},
+	{"Cow", func(work int) RW { return &CowRW{work: work} }},
+}
+
+// 每种实现并发读写后，写入次数必须准确
+func TestRWImpls(t *testing.T) {
+	const goroutines, writes = 8, 1000
+	for _, impl := range lockImpls {
+		rw := impl.new(defaultWork)
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < writes; i++ {
+					rw.Write()
+					rw.Read()
+				}
+			}()
+		}
+		wg.Wait()
+		var got int64
+		switch l := rw.(type) {
+		case *Lock:
+			got = int64(l.count)
+		case *RWLock:
+			got = int64(l.count)
+		case *AtomicRW:
+			got = l.count.Load()
+		case *ShardedRW:
+			got = int64(l.count)
+		case *SpinLock:
+			got = int64(l.count)
+		case *SeqLock:
+			got = l.count.Load()
+		case *CowRW:
+			got = int64(l.p.Load().count)
+		}
+		if got != goroutines*writes {
+			t.Errorf("%s: count = %d, want %d", impl.name, got, goroutines*writes)
+		}
+	}
+}
+
+var (
+	readPcts   = []int{50, 90, 99}
+	works      = []int{10, 500}
+	goroutines = []int{4, 64}
+)
+
+// go test -run xxx -bench Locks ./concurrency
+func BenchmarkLocks(b *testing.B) {
+	for _, w := range works {
+		for _, g := range goroutines {
+			for _, r := range readPcts {
+				for _, impl := range lockImpls {
+					name := fmt.Sprintf("work=%d/g=%d/read=%d/%s", w, g, r, impl.name)
+					b.Run(name, func(b *testing.B) {
+						benchmark(b, impl.new(w), workload{readPct: r, goroutines: g})
+					})
+				}
+			}
+		}
+	}
+}
+
+var crossover = flag.Bool("crossover", false, "print the lock crossover chart")
+
+// TestCrossover 在每种访问模式下测量所有实现的 ns/op，输出交叉对比图：
+// 每行一种读写比例，标出最快的实现，并用条形图表示与最快实现的差距，
+// 从中可以看出随着读比例、临界区开销和协程数变化，最优选择在哪里发生交叉。
+// 耗时较长，需要显式开启：
+//
+//	go test -run Crossover -cpu 1,4 ./concurrency -args -crossover
+//
+// 结果取决于 GOMAXPROCS：单核下协程之间不会真正并行，读锁、分片和无锁读的优势体现不出来。
+func TestCrossover(t *testing.T) {
+	if !*crossover {
+		t.Skip("use -crossover to print the chart")
+	}
+	writeCrossover(os.Stdout, []int{0, 50, 90, 99, 100})
+}
+
+func writeCrossover(out io.Writer, pcts []int) {
+	fmt.Fprintf(out, "GOMAXPROCS=%d\n", runtime.GOMAXPROCS(0))
+	for _, w := range works {
+		for _, g := range goroutines {
+			fmt.Fprintf(out, "\nwork=%d goroutines=%d (ns/op)\n", w, g)
+			fmt.Fprintf(out, "%-6s", "read%")
+			for _, impl := range lockImpls {
+				fmt.Fprintf(out, "%9s", impl.name)
+			}
+			fmt.Fprintf(out, "  %-8s\n", "best")
+			for _, r := range pcts {
+				ns := make([]float64, len(lockImpls))
+				best := 0
+				for i, impl := range lockImpls {
+					ns[i] = measure(impl.new(w), workload{readPct: r, goroutines: g})
+					if ns[i] < ns[best] {
+						best = i
+					}
+				}
+				fmt.Fprintf(out, "%-6d", r)
+				for _, v := range ns {
+					fmt.Fprintf(out, "%9.1f", v)
+				}
+				fmt.Fprintf(out, "  %-8s\n", lockImpls[best].name)
+				for i, v := range ns {
+					// 条形长度为相对最快实现的倍数，每个字符 0.25 倍，最长 40
+					n := int((v/ns[best] - 1) * 4)
+					if n > 40 {
+						n = 40
+					}
+					fmt.Fprintf(out, "      %-8s |%s\n", lockImpls[i].name, strings.Repeat("#", n+1))
+				}
+			}
+		}
+	}
+}
+
+// measure 执行足够多的操作（至少 50ms）后计算 ns/op
+func measure(rw RW, w workload) float64 {
+	for ops := 10000; ; ops *= 4 {
+		start := time.Now()
+		run(rw, w, ops)
+		if d := time.Since(start); d >= 50*time.Millisecond || ops >= 1<<24 {
+			return float64(d.Nanoseconds()) / float64(ops)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package safechan 提供可以安全关闭的泛型 channel。
+// goroutine_test.go 中的 MyChannel 只能包装 chan bool，并且只解决了重复关闭的问题；
+// 向已关闭的 channel 发送数据同样会 panic，这在多个发送者的场景下很难避免。
+// SafeChan 的 Close 可以重复调用，发送方在关闭后得到 ErrClosed 而不是 panic。
+package safechan
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrClosed 表示 channel 已关闭
+	ErrClosed = errors.New("safechan: send on closed channel")
+	// ErrFull 表示 TrySend 时缓冲区已满且没有等待的接收方
+	ErrFull = errors.New("safechan: channel is full")
+)
+
+// SafeChan 是可以安全关闭的 channel，接收方通过 C() 读取数据
+type SafeChan[T any] struct {
+	c    chan T
+	done chan struct{}
+	once sync.Once
+	// mu 保证 close(c) 时没有正在进行的发送：发送方持有读锁，Close 持有写锁
+	mu sync.RWMutex
+}
+
+// New 创建缓冲区大小为 size 的 SafeChan
+func New[T any](size int) *SafeChan[T] {
+	return &SafeChan[T]{
+		c:    make(chan T, size),
+		done: make(chan struct{}),
+	}
+}
+
+// C 返回用于接收数据的 channel，关闭后 range 会在读完缓冲区中的数据后结束
+func (c *SafeChan[T]) C() <-chan T { return c.c }
+
+// Done 返回一个在 Close 时关闭的 channel，用于观察关闭事件
+func (c *SafeChan[T]) Done() <-chan struct{} { return c.done }
+
+// Closed 报告是否已经调用过 Close
+func (c *SafeChan[T]) Closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Send 发送 v，阻塞直到发送成功、channel 关闭（返回 ErrClosed）或 ctx 取消（返回 ctx.Err()）
+func (c *SafeChan[T]) Send(ctx context.Context, v T) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Closed() {
+		return ErrClosed
+	}
+	select {
+	case c.c <- v:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend 尝试发送 v 而不阻塞，缓冲区已满时返回 ErrFull，已关闭时返回 ErrClosed
+func (c *SafeChan[T]) TrySend(v T) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Closed() {
+		return ErrClosed
+	}
+	select {
+	case c.c <- v:
+		return nil
+	default:
+		return ErrFull
+	}
+}
+
+// Close 关闭 channel，可以重复调用，也可以与 Send 并发调用。
+// 阻塞在 Send 中的发送方会立即返回 ErrClosed，已经在缓冲区中的数据仍然可以被接收。
+func (c *SafeChan[T]) Close() {
+	c.once.Do(func() {
+		// 先关闭 done 唤醒阻塞的发送方，让它们释放读锁，再在写锁保护下关闭数据 channel
+		close(c.done)
+		c.mu.Lock()
+		close(c.c)
+		c.mu.Unlock()
+	})
+}
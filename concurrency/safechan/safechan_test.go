@@ -0,0 +1,144 @@
+package safechan
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSendRecv(t *testing.T) {
+	c := New[int](3)
+	go func() {
+		for i := 0; i < 10; i++ {
+			if err := c.Send(context.Background(), i); err != nil {
+				t.Error(err)
+			}
+		}
+		c.Close()
+	}()
+	sum := 0
+	for v := range c.C() {
+		sum += v
+	}
+	if sum != 45 {
+		t.Fatalf("got %d, want 45", sum)
+	}
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	c := New[int](1)
+	c.Close()
+	c.Close()
+	if !c.Closed() {
+		t.Fatal("Closed() = false after Close")
+	}
+	if err := c.Send(context.Background(), 1); err != ErrClosed {
+		t.Fatalf("got %v, want ErrClosed", err)
+	}
+	if err := c.TrySend(1); err != ErrClosed {
+		t.Fatalf("got %v, want ErrClosed", err)
+	}
+}
+
+func TestTrySend(t *testing.T) {
+	c := New[string](1)
+	if err := c.TrySend("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TrySend("b"); err != ErrFull {
+		t.Fatalf("got %v, want ErrFull", err)
+	}
+	c.Close()
+	// 关闭前发送的数据仍然可以接收
+	if v, ok := <-c.C(); v != "a" || !ok {
+		t.Fatalf("got %q %v", v, ok)
+	}
+	if _, ok := <-c.C(); ok {
+		t.Fatal("channel should be drained and closed")
+	}
+}
+
+func TestSendCancel(t *testing.T) {
+	c := New[int](0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Send(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+}
+
+// 多个发送者与 Close 并发，不能出现 send on closed channel 的 panic
+func TestConcurrentClose(t *testing.T) {
+	for round := 0; round < 100; round++ {
+		c := New[int](0)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for {
+					if err := c.Send(context.Background(), i); err != nil {
+						if err != ErrClosed {
+							t.Error(err)
+						}
+						return
+					}
+				}
+			}(i)
+		}
+		go func() {
+			for range c.C() {
+			}
+		}()
+		time.Sleep(time.Millisecond)
+		c.Close()
+		wg.Wait()
+	}
+}
+
+// Send 需要额外获取读锁并在 select 中多监听两个 channel，单次开销约为原生 channel 的 2~3 倍
+func BenchmarkRawChan(b *testing.B) {
+	c := make(chan int, 128)
+	go func() {
+		for range c {
+		}
+	}()
+	for i := 0; i < b.N; i++ {
+		c <- i
+	}
+	close(c)
+}
+
+func BenchmarkSafeChanSend(b *testing.B) {
+	c := New[int](128)
+	go func() {
+		for range c.C() {
+		}
+	}()
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		c.Send(ctx, i)
+	}
+	c.Close()
+}
+
+// 非阻塞发送在同一个协程内收发，避免忙等的发送方抢占接收方
+func BenchmarkRawChanSelect(b *testing.B) {
+	c := make(chan int, 1)
+	for i := 0; i < b.N; i++ {
+		select {
+		case c <- i:
+		default:
+		}
+		<-c
+	}
+}
+
+func BenchmarkSafeChanTrySend(b *testing.B) {
+	c := New[int](1)
+	for i := 0; i < b.N; i++ {
+		c.TrySend(i)
+		<-c.C()
+	}
+}
@@ -0,0 +1,121 @@
+// Package semaphore 提供带权重的信号量。
+// TestRoutineNum 用容量为 3 的 chan struct{} 限制并发数，每个协程只能占用 1 个名额；
+// 按请求字节数限流这类场景需要一次占用多个名额，channel 无法表达。
+//
+// 等待者按 FIFO 顺序获取，队首的大权重请求没有满足时，后来的小权重请求也必须排队，
+// 否则大权重请求可能一直得不到执行（饥饿）。
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrTooLarge 表示请求的权重超过了信号量的容量，永远无法满足
+var ErrTooLarge = errors.New("semaphore: weight exceeds size")
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // 获取成功时关闭
+}
+
+// Semaphore 是带权重的信号量，零值不可用，需要使用 New 创建
+type Semaphore struct {
+	size    int64
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List
+}
+
+// New 创建容量为 n 的信号量
+func New(n int64) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire 获取权重为 n 的名额，阻塞直到成功或 ctx 取消。
+// 失败时返回 ctx.Err()，且不会占用任何名额；n 大于容量时立即返回 ErrTooLarge。
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return ErrTooLarge
+	}
+	done := ctx.Done()
+	s.mu.Lock()
+	select {
+	case <-done:
+		// ctx 已经取消时不获取，即使有空闲名额
+		s.mu.Unlock()
+		return ctx.Err()
+	default:
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(waiter{n: n, ready: ready})
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-done:
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// 取消的同时已经获取成功，归还名额，假装没有获取到
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// 队首的等待者离开后，后面的等待者可能可以被满足了
+			if front && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 尝试获取权重为 n 的名额，不阻塞，成功时返回 true
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release 归还权重为 n 的名额，归还的比已获取的多时 panic
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters 按 FIFO 顺序唤醒能够满足的等待者，遇到第一个不能满足的就停止
+func (s *Semaphore) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,147 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 与 TestRoutineNum 相同的场景，最多同时运行 3 个协程
+func TestLimit(t *testing.T) {
+	sem := New(3)
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		if err := sem.Acquire(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.Release(1)
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if peak.Load() > 3 {
+		t.Fatalf("peak concurrency %d exceeds 3", peak.Load())
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	sem := New(10)
+	if !sem.TryAcquire(7) {
+		t.Fatal("TryAcquire(7) failed")
+	}
+	if sem.TryAcquire(4) {
+		t.Fatal("TryAcquire(4) should fail with 3 left")
+	}
+	if !sem.TryAcquire(3) {
+		t.Fatal("TryAcquire(3) failed")
+	}
+	sem.Release(10)
+	if err := sem.Acquire(context.Background(), 11); err != ErrTooLarge {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+}
+
+// 队首的大权重请求在等待时，后来的小权重请求不能插队
+func TestFIFO(t *testing.T) {
+	sem := New(10)
+	sem.Acquire(context.Background(), 5)
+	big := make(chan struct{})
+	go func() {
+		sem.Acquire(context.Background(), 10)
+		close(big)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if sem.TryAcquire(1) {
+		t.Fatal("small request jumped ahead of waiting large request")
+	}
+	sem.Release(5)
+	select {
+	case <-big:
+	case <-time.After(time.Second):
+		t.Fatal("large request was not granted")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	sem := New(2)
+	sem.Acquire(context.Background(), 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sem.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+	sem.Release(2)
+	// 取消的等待者不能占用名额
+	if !sem.TryAcquire(2) {
+		t.Fatal("cancelled waiter still holds weight")
+	}
+}
+
+// 取消队首的大权重请求后，排在后面的小权重请求应被唤醒
+func TestCancelFront(t *testing.T) {
+	sem := New(10)
+	sem.Acquire(context.Background(), 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	go sem.Acquire(ctx, 10)
+	time.Sleep(10 * time.Millisecond)
+	small := make(chan struct{})
+	go func() {
+		sem.Acquire(context.Background(), 1)
+		close(small)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-small:
+	case <-time.After(time.Second):
+		t.Fatal("small request was not granted after front waiter cancelled")
+	}
+}
+
+// 无竞争时两者开销相近；高并发下 Semaphore 需要抢同一把互斥锁，单次开销约为 channel 的 2~3 倍。
+// 只需要按个数限流时 channel 足够，需要按权重限流或可取消的等待时再使用 Semaphore
+func BenchmarkChannel(b *testing.B) {
+	ch := make(chan struct{}, 3)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- struct{}{}
+			<-ch
+		}
+	})
+}
+
+func BenchmarkSemaphore(b *testing.B) {
+	sem := New(3)
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sem.Acquire(ctx, 1)
+			sem.Release(1)
+		}
+	})
+}
+
+func BenchmarkSemaphoreTry(b *testing.B) {
+	sem := New(3)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if sem.TryAcquire(1) {
+				sem.Release(1)
+			}
+		}
+	})
+}
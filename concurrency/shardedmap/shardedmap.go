@@ -0,0 +1,231 @@
+// Package shardedmap 提供分片加锁的泛型并发 map。
+// sync.Map 针对两种场景做了优化：key 只写一次之后反复读取，或者多个协程读写互不相交的 key。
+// 其他场景（例如写入频繁、key 不断变化）下，sync.Map 的 dirty map 提升和 interface{} 装箱反而更慢。
+// ShardedMap 把 key 按哈希分到多个分片，每个分片是一把读写锁加一个普通 map，
+// 不同分片上的操作互不影响，并且不需要把 key 和 value 转换为 interface{}。
+package shardedmap
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// DefaultShards 是分片数小于等于 0 时使用的分片数
+const DefaultShards = 32
+
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+	// 补齐到 128 字节，避免相邻分片的锁落在同一缓存行上（伪共享）
+	_ [128 - 32]byte
+}
+
+// ShardedMap 是分片加锁的并发 map，需要使用 New 创建
+type ShardedMap[K comparable, V any] struct {
+	shards []shard[K, V]
+	mask   uint64
+	hash   func(K) uint64
+}
+
+// New 创建分片数为 shards 的 ShardedMap，分片数会向上取整为 2 的幂。
+// hash 为 nil 时使用 DefaultHasher，K 不是 DefaultHasher 支持的类型时 panic。
+// 自定义的 hash 必须保证 == 相等的 key 得到相同的哈希值。
+func New[K comparable, V any](shards int, hash func(K) uint64) *ShardedMap[K, V] {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	if hash == nil {
+		if hash = DefaultHasher[K](); hash == nil {
+			var zero K
+			panic(fmt.Sprintf("shardedmap: no default hasher for key type %T, pass a hash function to New", zero))
+		}
+	}
+	m := &ShardedMap[K, V]{shards: make([]shard[K, V], n), mask: uint64(n - 1), hash: hash}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m
+}
+
+// DefaultHasher 返回 K 的默认哈希函数，支持底层类型为字符串、整数或浮点数的 key（包括 type ID string 这样的命名类型）：
+// 字符串使用 maphash，整数和浮点数使用位混合。其他类型（结构体、数组、指针、接口等）返回 nil，需要通过 New 传入哈希函数。
+//
+// 不能退化为对 fmt.Sprint 的结果求哈希：== 相等的 key 格式化后可能不同，
+// 例如 0.0 和 -0.0，或者 String 方法与相等性不一致的类型，它们会被分到不同的分片，Load 找不到已经存入的 key。
+func DefaultHasher[K comparable]() func(K) uint64 {
+	var zero K
+	t := reflect.TypeOf(zero)
+	if t == nil {
+		// K 是接口类型
+		return nil
+	}
+	// 下面按底层类型读取 key 的内存，命名类型与底层类型的内存布局和相等性都相同
+	switch t.Kind() {
+	case reflect.String:
+		seed := maphash.MakeSeed()
+		return func(k K) uint64 { return maphash.String(seed, *(*string)(unsafe.Pointer(&k))) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch t.Size() {
+		case 1:
+			return func(k K) uint64 { return mix(uint64(*(*uint8)(unsafe.Pointer(&k)))) }
+		case 2:
+			return func(k K) uint64 { return mix(uint64(*(*uint16)(unsafe.Pointer(&k)))) }
+		case 4:
+			return func(k K) uint64 { return mix(uint64(*(*uint32)(unsafe.Pointer(&k)))) }
+		case 8:
+			return func(k K) uint64 { return mix(*(*uint64)(unsafe.Pointer(&k))) }
+		}
+	case reflect.Float32:
+		return func(k K) uint64 {
+			f := *(*float32)(unsafe.Pointer(&k))
+			if f == 0 {
+				f = 0 // -0 == 0，统一为 +0 的位模式
+			}
+			return mix(uint64(math.Float32bits(f)))
+		}
+	case reflect.Float64:
+		return func(k K) uint64 {
+			f := *(*float64)(unsafe.Pointer(&k))
+			if f == 0 {
+				f = 0
+			}
+			return mix(math.Float64bits(f))
+		}
+	}
+	return nil
+}
+
+// mix 是 splitmix64 的终结函数，把相邻的整数打散到不同的分片
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func (m *ShardedMap[K, V]) shard(k K) *shard[K, V] {
+	return &m.shards[m.hash(k)&m.mask]
+}
+
+// Load 返回 key 对应的值，ok 表示 key 是否存在
+func (m *ShardedMap[K, V]) Load(key K) (value V, ok bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	value, ok = s.m[key]
+	s.mu.RUnlock()
+	return
+}
+
+// Store 设置 key 对应的值
+func (m *ShardedMap[K, V]) Store(key K, value V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	s.m[key] = value
+	s.mu.Unlock()
+}
+
+// LoadOrStore 在 key 存在时返回已有的值，loaded 为 true；否则存入 value 并返回 value
+func (m *ShardedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shard(key)
+	// 大部分调用 key 已经存在，先用读锁检查
+	s.mu.RLock()
+	actual, loaded = s.m[key]
+	s.mu.RUnlock()
+	if loaded {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if actual, loaded = s.m[key]; loaded {
+		return
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// LoadOrCompute 在 key 存在时返回已有的值，否则调用 fn 计算并存入。
+// 同一个 key 的 fn 至多执行一次成功的存入，fn 在分片的写锁内执行，
+// 不能再访问同一个 ShardedMap，并且应当尽量快，否则会阻塞同一分片上的其他 key。
+func (m *ShardedMap[K, V]) LoadOrCompute(key K, fn func() V) (actual V, loaded bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	actual, loaded = s.m[key]
+	s.mu.RUnlock()
+	if loaded {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if actual, loaded = s.m[key]; loaded {
+		return
+	}
+	actual = fn()
+	s.m[key] = actual
+	return actual, false
+}
+
+// LoadAndDelete 删除 key 并返回删除前的值
+func (m *ShardedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	value, loaded = s.m[key]
+	delete(s.m, key)
+	s.mu.Unlock()
+	return
+}
+
+// Delete 删除 key
+func (m *ShardedMap[K, V]) Delete(key K) {
+	s := m.shard(key)
+	s.mu.Lock()
+	delete(s.m, key)
+	s.mu.Unlock()
+}
+
+// Len 返回元素个数，并发修改时只是近似值
+func (m *ShardedMap[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Range 依次对每个元素调用 f，f 返回 false 时停止。
+// 与 sync.Map 相同，Range 不是一致性快照：逐个分片复制后在锁外调用 f，
+// 因此 f 中可以修改 map，遍历期间其他协程的修改可能看得到也可能看不到。
+func (m *ShardedMap[K, V]) Range(f func(key K, value V) bool) {
+	type entry struct {
+		k K
+		v V
+	}
+	var entries []entry
+	for i := range m.shards {
+		s := &m.shards[i]
+		entries = entries[:0]
+		s.mu.RLock()
+		for k, v := range s.m {
+			entries = append(entries, entry{k, v})
+		}
+		s.mu.RUnlock()
+		for _, e := range entries {
+			if !f(e.k, e.v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package shardedmap
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestShardedMap(t *testing.T) {
+	m := New[string, int](3, nil)
+	if len(m.shards) != 4 {
+		t.Fatalf("shards = %d, want 4", len(m.shards))
+	}
+	for i := 0; i < 100; i++ {
+		m.Store(strconv.Itoa(i), i)
+	}
+	if v, ok := m.Load("42"); !ok || v != 42 {
+		t.Fatalf("Load(42) = %d, %v", v, ok)
+	}
+	if v, loaded := m.LoadOrStore("42", 0); !loaded || v != 42 {
+		t.Fatalf("LoadOrStore(42) = %d, %v", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("100", 100); loaded || v != 100 {
+		t.Fatalf("LoadOrStore(100) = %d, %v", v, loaded)
+	}
+	if v, loaded := m.LoadAndDelete("100"); !loaded || v != 100 {
+		t.Fatalf("LoadAndDelete(100) = %d, %v", v, loaded)
+	}
+	m.Delete("0")
+	if _, ok := m.Load("0"); ok || m.Len() != 99 {
+		t.Fatalf("Delete failed, len = %d", m.Len())
+	}
+	sum, n := 0, 0
+	m.Range(func(k string, v int) bool {
+		sum += v
+		n++
+		m.Delete(k) // Range 中修改 map 不会死锁
+		return true
+	})
+	if n != 99 || sum != 99*100/2 || m.Len() != 0 {
+		t.Fatalf("Range visited %d entries, sum %d, len %d", n, sum, m.Len())
+	}
+}
+
+func TestRangeStop(t *testing.T) {
+	m := New[int, int](0, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	n := 0
+	m.Range(func(int, int) bool {
+		n++
+		return n < 10
+	})
+	if n != 10 {
+		t.Fatalf("Range visited %d entries after stop", n)
+	}
+}
+
+// 并发调用 LoadOrCompute，同一个 key 只计算一次
+func TestLoadOrCompute(t *testing.T) {
+	m := New[int, *int](4, nil)
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	results := make([]*int, 16)
+	for g := range results {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			results[g], _ = m.LoadOrCompute(1, func() *int {
+				calls.Add(1)
+				return new(int)
+			})
+		}(g)
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("fn called %d times", calls.Load())
+	}
+	for _, r := range results {
+		if r != results[0] {
+			t.Fatal("got different values for the same key")
+		}
+	}
+}
+
+type point struct{ x, y int }
+
+func TestCustomHasher(t *testing.T) {
+	m := New[point, string](8, func(p point) uint64 { return uint64(p.x*31 + p.y) })
+	m.Store(point{1, 2}, "a")
+	if v, _ := m.Load(point{1, 2}); v != "a" {
+		t.Fatalf("Load = %q", v)
+	}
+	// 结构体没有默认的哈希函数，必须传入
+	defer func() {
+		if recover() == nil {
+			t.Fatal("New without hasher for struct key did not panic")
+		}
+	}()
+	New[point, string](8, nil)
+}
+
+type id string
+
+// String 与相等性不一致，默认哈希函数不能依赖格式化的结果
+func (id) String() string { return "same" }
+
+type level int8
+
+func TestDefaultHasher(t *testing.T) {
+	// 0.0 和 -0.0 作为 map 的 key 是相等的
+	negZero := math.Copysign(0, -1)
+	f := New[float64, int](64, nil)
+	f.Store(negZero, 1)
+	if v, ok := f.Load(0); !ok || v != 1 {
+		t.Fatalf("Load(0) after Store(-0) = %d, %v", v, ok)
+	}
+	if _, loaded := f.LoadOrStore(0, 2); !loaded || f.Len() != 1 {
+		t.Fatalf("LoadOrStore(0) stored a duplicate, len %d", f.Len())
+	}
+
+	// 命名类型按底层类型哈希，不同的值分布到不同的分片
+	ids := New[id, int](64, nil)
+	levels := New[level, int](64, nil)
+	for i := 0; i < 100; i++ {
+		ids.Store(id(strconv.Itoa(i)), i)
+		levels.Store(level(i-50), i)
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := ids.Load(id(strconv.Itoa(i))); !ok || v != i {
+			t.Fatalf("ids.Load(%d) = %d, %v", i, v, ok)
+		}
+		if v, ok := levels.Load(level(i - 50)); !ok || v != i {
+			t.Fatalf("levels.Load(%d) = %d, %v", i-50, v, ok)
+		}
+	}
+	used := 0
+	for i := range ids.shards {
+		if len(ids.shards[i].m) > 0 {
+			used++
+		}
+	}
+	if used < 32 {
+		t.Fatalf("100 id keys used only %d of 64 shards", used)
+	}
+
+	if DefaultHasher[any]() != nil || DefaultHasher[*int]() != nil || DefaultHasher[[2]int]() != nil {
+		t.Fatal("DefaultHasher should not support interface, pointer or array keys")
+	}
+}
+
+// 整数 key 经过混合后应当均匀地分布在各个分片上
+func TestDistribution(t *testing.T) {
+	m := New[int, int](16, nil)
+	for i := 0; i < 16000; i++ {
+		m.Store(i, i)
+	}
+	for i := range m.shards {
+		if n := len(m.shards[i].m); n < 800 || n > 1200 {
+			t.Errorf("shard %d has %d keys", i, n)
+		}
+	}
+}
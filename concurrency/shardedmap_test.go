@@ -0,0 +1,87 @@
+package concurrency
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"highPerformance/concurrency/shardedmap"
+)
+
+// 用 mutex_test.go 中的 benchmark 对比三种并发 map：Read 读取一个随机 key，Write 写入一个随机 key。
+// 全局的 rand.Uint32 在 Go 1.20 之后不加锁，不会成为瓶颈。
+const mapKeys = 1 << 12
+
+type syncMapRW struct{ m sync.Map }
+
+func (m *syncMapRW) Read()  { m.m.Load(int(rand.Uint32() % mapKeys)) }
+func (m *syncMapRW) Write() { k := int(rand.Uint32() % mapKeys); m.m.Store(k, k) }
+
+type rwMutexMap struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+func (m *rwMutexMap) Read() {
+	k := int(rand.Uint32() % mapKeys)
+	m.mu.RLock()
+	_ = m.m[k]
+	m.mu.RUnlock()
+}
+
+func (m *rwMutexMap) Write() {
+	k := int(rand.Uint32() % mapKeys)
+	m.mu.Lock()
+	m.m[k] = k
+	m.mu.Unlock()
+}
+
+type shardedMapRW struct {
+	m *shardedmap.ShardedMap[int, int]
+}
+
+func (m shardedMapRW) Read()  { m.m.Load(int(rand.Uint32() % mapKeys)) }
+func (m shardedMapRW) Write() { k := int(rand.Uint32() % mapKeys); m.m.Store(k, k) }
+
+// 预先写入所有 key，benchmark 测的是稳定状态下的读写，而不是 map 扩容。
+// map 的操作本身就是临界区，没有额外的忙等，忽略 work
+func newMaps() []lockImpl {
+	return []lockImpl{
+		{"SyncMap", func(int) RW {
+			m := &syncMapRW{}
+			for k := 0; k < mapKeys; k++ {
+				m.m.Store(k, k)
+			}
+			return m
+		}},
+		{"RWMutexMap", func(int) RW {
+			m := &rwMutexMap{m: make(map[int]int, mapKeys)}
+			for k := 0; k < mapKeys; k++ {
+				m.m[k] = k
+			}
+			return m
+		}},
+		{"ShardedMap", func(int) RW {
+			m := shardedMapRW{shardedmap.New[int, int](0, nil)}
+			for k := 0; k < mapKeys; k++ {
+				m.m.Store(k, k)
+			}
+			return m
+		}},
+	}
+}
+
+// go test -run xxx -bench Maps -cpu 1,4 ./concurrency
+//
+// 单核上没有真正的并行，读写锁不存在争用，RWMutexMap 和 ShardedMap 相差无几，
+// sync.Map 因为 key 装箱为 interface{} 而最慢；分片的优势需要在多核上才能体现。
+func BenchmarkMaps(b *testing.B) {
+	for _, r := range []int{0, 50, 90, 99, 100} {
+		for _, impl := range newMaps() {
+			b.Run(fmt.Sprintf("read=%d/%s", r, impl.name), func(b *testing.B) {
+				benchmark(b, impl.new(0), workload{readPct: r, goroutines: 16})
+			})
+		}
+	}
+}
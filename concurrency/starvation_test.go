@@ -0,0 +1,187 @@
+package concurrency
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TicketLock 是严格 FIFO 的排号锁：先取号，叫到自己的号才能进入，不存在插队，也就不会饥饿，
+// 但是每次交接都要等到下一个号的协程被调度，吞吐量低。
+type TicketLock struct {
+	next    atomic.Uint32
+	serving atomic.Uint32
+}
+
+func (l *TicketLock) Lock() {
+	t := l.next.Add(1) - 1
+	for l.serving.Load() != t {
+		runtime.Gosched()
+	}
+}
+
+func (l *TicketLock) Unlock() {
+	l.serving.Add(1)
+}
+
+// ChanLock 用容量为 1 的 channel 实现互斥锁，阻塞在 channel 上的协程按 FIFO 顺序被唤醒，
+// 但刚释放锁的协程如果立刻再次加锁，依然可以插队。
+type ChanLock chan struct{}
+
+func NewChanLock() ChanLock {
+	return make(ChanLock, 1)
+}
+
+func (l ChanLock) Lock()   { l <- struct{}{} }
+func (l ChanLock) Unlock() { <-l }
+
+// latencies 是一组加锁等待时间
+type latencies []time.Duration
+
+func (l latencies) percentile(p float64) time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(l)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return l[i]
+}
+
+type starvationResult struct {
+	name        string
+	short, long latencies // 已排序
+	// fairness 是 short 组各协程获取锁次数的 Jain 公平性指数，1 表示完全均等，1/n 表示被一个协程独占。
+	// long 组协程每次加锁前都会睡眠，获取次数天然较少，不参与计算。
+	fairness float64
+}
+
+// starvation 启动 shortN 个协程持续地加锁、做极短的计算、解锁后立刻再次加锁，
+// 以及 longN 个协程每隔 1ms 才来加一次锁。持续 d 后统计两组协程的等待时间和获取次数。
+// 前者总是在运行中，刚释放锁就能再次抢到；后者每次都是被唤醒的那一方，最容易饥饿。
+func starvation(name string, l sync.Locker, shortN, longN int, d time.Duration) starvationResult {
+	var (
+		stop  atomic.Bool
+		wg    sync.WaitGroup
+		lats  = make([]latencies, shortN+longN)
+		count = make([]int, shortN+longN)
+	)
+	for g := 0; g < shortN+longN; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			long := g >= shortN
+			for !stop.Load() {
+				if long {
+					time.Sleep(time.Millisecond)
+				}
+				start := time.Now()
+				l.Lock()
+				lats[g] = append(lats[g], time.Since(start))
+				busy(10)
+				l.Unlock()
+				count[g]++
+			}
+		}(g)
+	}
+	time.Sleep(d)
+	stop.Store(true)
+	wg.Wait()
+
+	r := starvationResult{name: name}
+	var sum, sq float64
+	for g := range lats {
+		if g < shortN {
+			r.short = append(r.short, lats[g]...)
+		} else {
+			r.long = append(r.long, lats[g]...)
+		}
+	}
+	for _, c := range count[:shortN] {
+		sum += float64(c)
+		sq += float64(c) * float64(c)
+	}
+	sort.Slice(r.short, func(i, j int) bool { return r.short[i] < r.short[j] })
+	sort.Slice(r.long, func(i, j int) bool { return r.long[i] < r.long[j] })
+	r.fairness = sum * sum / (float64(shortN) * sq)
+	return r
+}
+
+func (r starvationResult) String() string {
+	var b strings.Builder
+	for _, g := range []struct {
+		name string
+		l    latencies
+	}{{"short", r.short}, {"long", r.long}} {
+		fmt.Fprintf(&b, "%-10s %-6s %9d %10v %10v %10v %10v\n", r.name, g.name, len(g.l),
+			g.l.percentile(50), g.l.percentile(99), g.l.percentile(99.9), g.l.percentile(100))
+	}
+	return b.String()
+}
+
+// TestStarvation 把 mutex_test.go 中对正常模式和饥饿模式的说明变成可复现的数据。
+// long 组协程每 1ms 加一次锁，理想情况下 200ms 内每个协程能获取约 200 次，
+// 实际获取次数和等待时间反映了它们被 short 组插队的程度。
+//
+// 单核（GOMAXPROCS=1）上的一次结果：sync.Mutex 的 long 组只获取了 8 次，p50 等待 20ms，
+// TicketLock 和 ChanLock 的 long 组都获取了 350 次左右，p50 只有几微秒。
+// 原因是 short 组协程从不阻塞，持有 CPU 直到 10ms 的时间片用完被抢占：
+// 饥饿模式能把锁直接交给等待者，却无法让等待者更早被调度。
+// TicketLock 和 ChanLock 在释放锁后让出了 CPU，公平的代价是 short 组的吞吐量下降到 1/4 和 1/2。
+// 多核上等待者可以在其他 P 上运行，饥饿模式的作用会更明显，可以用 go test -cpu 在多核机器上对比；
+// 注意 CPU 核数少于 GOMAXPROCS 时，TicketLock 等待者的自旋会和持有者抢 CPU，结果没有参考价值。
+func TestStarvation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	const shortN, longN, d = 8, 2, 200 * time.Millisecond
+	results := []starvationResult{
+		starvation("Mutex", &sync.Mutex{}, shortN, longN, d),
+		starvation("Ticket", &TicketLock{}, shortN, longN, d),
+		starvation("Chan", NewChanLock(), shortN, longN, d),
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "GOMAXPROCS=%d %d short holders, %d long waiters, %v\n", runtime.GOMAXPROCS(0), shortN, longN, d)
+	fmt.Fprintf(&b, "%-10s %-6s %9s %10s %10s %10s %10s\n", "lock", "group", "acquired", "p50", "p99", "p999", "max")
+	for _, r := range results {
+		b.WriteString(r.String())
+	}
+	for _, r := range results {
+		fmt.Fprintf(&b, "%-10s fairness %.3f\n", r.name, r.fairness)
+	}
+	t.Log("\n" + b.String())
+	for _, r := range results {
+		if len(r.long) == 0 {
+			t.Errorf("%s: long waiters never acquired the lock", r.name)
+		}
+	}
+}
+
+func TestLocks(t *testing.T) {
+	for _, l := range []sync.Locker{&TicketLock{}, NewChanLock()} {
+		count := 0
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					l.Lock()
+					count++
+					l.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if count != 8000 {
+			t.Errorf("%T: count = %d", l, count)
+		}
+	}
+}
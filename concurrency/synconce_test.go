@@ -8,6 +8,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"highPerformance/concurrency/lazy"
 )
 
 // 在多数情况下，sync.Once 被用于控制变量的初始化，这个变量的读写满足如下三个条件：
@@ -48,6 +50,33 @@ func TestReadConfig(t *testing.T) {
 	time.Sleep(time.Second)
 }
 
+// ReadConfig 把 TT_PORT 的解析错误吞掉了，而且 sync.Once 执行过一次后就不会再执行，即使初始化失败了。
+// lazy.OnceErr 会把错误返回给调用方，失败后的下一次调用会重新初始化
+var configOnce = lazy.NewOnceErr(func() (*Config, error) {
+	port, err := strconv.ParseInt(os.Getenv("TT_PORT"), 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TT_PORT: %w", err)
+	}
+	return &Config{Server: os.Getenv("TT_SERVER_URL"), Port: port}, nil
+})
+
+func ReadConfigErr() (*Config, error) {
+	return configOnce.Get()
+}
+
+func TestReadConfigErr(t *testing.T) {
+	defer configOnce.Reset()
+	t.Setenv("TT_PORT", "not-a-port")
+	if _, err := ReadConfigErr(); err == nil {
+		t.Fatal("expected error for invalid TT_PORT")
+	}
+	t.Setenv("TT_PORT", "9090")
+	config, err := ReadConfigErr()
+	if err != nil || config.Port != 9090 {
+		t.Fatalf("got %+v %v after fixing TT_PORT", config, err)
+	}
+}
+
 // sync.Once实现源码, 代码位于 $(dirname $(which go))/../src/sync/once.go
 // 首先：保证变量仅被初始化一次，需要有个标志来判断变量是否已初始化过，若没有则需要初始化。
 // 第二：线程安全，支持并发，无疑需要互斥锁来实现。
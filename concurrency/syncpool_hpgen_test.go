@@ -0,0 +1,102 @@
+// Code generated by hpgen. DO NOT EDIT.
+
+package concurrency
+
+import (
+	"strconv"
+
+	"highPerformance/serialize/hpjson"
+)
+
+// MarshalJSON 实现 json.Marshaler，输出与 encoding/json 相同
+func (v *Student) MarshalJSON() ([]byte, error) {
+	return v.AppendJSON(make([]byte, 0, 2107))
+}
+
+// AppendJSON 把 v 编码为 JSON 对象追加到 dst
+func (v *Student) AppendJSON(dst []byte) ([]byte, error) {
+	n := len(dst)
+	dst = append(dst, `,"Name":`...)
+	dst = hpjson.AppendString(dst, v.Name)
+	dst = append(dst, `,"Age":`...)
+	dst = strconv.AppendInt(dst, int64(v.Age), 10)
+	dst = append(dst, `,"Remark":`...)
+	dst = append(dst, '[')
+	for i, e := range v.Remark {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = strconv.AppendUint(dst, uint64(e), 10)
+	}
+	dst = append(dst, ']')
+	if len(dst) == n {
+		dst = append(dst, '{')
+	} else {
+		dst[n] = '{'
+	}
+	return append(dst, '}'), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，接受的输入和解码结果与 encoding/json 相同
+func (v *Student) UnmarshalJSON(data []byte) error {
+	var l hpjson.Lexer
+	l.Reset(data)
+	v.DecodeJSON(&l)
+	return l.End()
+}
+
+// DecodeJSON 从 l 读取一个 JSON 对象解码到 v，错误记录在 l 中
+func (v *Student) DecodeJSON(l *hpjson.Lexer) {
+	if l.Null() {
+		return
+	}
+	l.BeginObject()
+	for l.NextKey() {
+		f := -1
+		switch string(l.Key()) {
+		case "Name":
+			f = 0
+		case "Age":
+			f = 1
+		case "Remark":
+			f = 2
+		default:
+			switch string(l.FoldedKey()) {
+			case "NAME":
+				f = 0
+			case "AGE":
+				f = 1
+			case "REMARK":
+				f = 2
+			}
+		}
+		switch f {
+		case 0:
+			if r, ok := l.String("string"); ok {
+				v.Name = r
+			}
+		case 1:
+			if r, ok := l.Int(32, "int32"); ok {
+				v.Age = int32(r)
+			}
+		case 2:
+			if !l.Null() {
+				i := 0
+				for l.BeginArray(); l.NextElem(); i++ {
+					if i < len(v.Remark) {
+						if r, ok := l.Uint(8, "byte"); ok {
+							v.Remark[i] = byte(r)
+						}
+					} else {
+						l.Skip()
+					}
+				}
+				for ; i < len(v.Remark); i++ {
+					v.Remark[i] = 0
+				}
+			}
+		default:
+			l.Skip()
+		}
+	}
+}
@@ -8,12 +8,19 @@ import (
 	"encoding/json"
 	"sync"
 	"testing"
+
+	"highPerformance/concurrency/bufpool"
+	"highPerformance/concurrency/typedpool"
 )
 
 // Go 语言从 1.3 版本开始提供了对象重用的机制，即 sync.Pool。sync.Pool 是可伸缩的，同时也是并发安全的，其大小仅受限于内存的大小。
 //sync.Pool 用于存储那些被分配了但是没有被使用，而未来可能会使用的值。这样就可以不用再次经过内存分配，可直接复用已有对象，减轻 GC 的压力，从而提升系统的性能。
 // sync.Pool 的大小是可伸缩的，高负载时会动态扩容，存放在池中的对象如果不活跃了会被自动清理。
 
+// Student 带有 hpgen 注释，syncpool_hpgen_test.go 中生成了不使用反射的 MarshalJSON 和 UnmarshalJSON
+//
+//go:generate go run highPerformance/cmd/hpgen .
+//hpgen:json
 type Student struct {
 	Name   string
 	Age    int32
@@ -30,14 +37,45 @@ var studentPool = sync.Pool{
 	},
 }
 
+// Student 有了生成的 UnmarshalJSON 之后，json.Unmarshal 会调用它。
+// 为了继续测量反射的开销，与对象池相关的实验（包括下面的 TestPoolReset 和 BenchmarkUnmarshalWithTypedPool）
+// 都把 *Student 转换为没有方法的 *plainStudent，只有名字中带 Generated 的 benchmark 使用生成的代码。
+type plainStudent Student
+
 func BenchmarkUnmarshal(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		stu := &Student{}
-		json.Unmarshal(buf, stu)
+		json.Unmarshal(buf, (*plainStudent)(stu))
 	}
 }
 
 func BenchmarkUnmarshalWithpool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stu := studentPool.Get().(*Student)
+		json.Unmarshal(buf, (*plainStudent)(stu))
+		studentPool.Put(stu)
+	}
+}
+
+// 生成的代码没有反射，Remark 的 1024 个元素直接在输入上边扫描边累加数字，
+// 单核环境下约 14µs/op，反射版本约 47µs/op；Marshal 约 5µs/op 且没有内存分配，反射版本约 20µs/op
+func BenchmarkUnmarshalGenerated(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stu := &Student{}
+		stu.UnmarshalJSON(buf)
+	}
+}
+
+func BenchmarkUnmarshalGeneratedWithpool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stu := studentPool.Get().(*Student)
+		stu.UnmarshalJSON(buf)
+		studentPool.Put(stu)
+	}
+}
+
+// 通过 json.Unmarshal 调用生成的方法，encoding/json 仍会先完整地扫描一遍输入检查语法
+func BenchmarkUnmarshalGeneratedViaJSON(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		stu := studentPool.Get().(*Student)
 		json.Unmarshal(buf, stu)
@@ -45,6 +83,78 @@ func BenchmarkUnmarshalWithpool(b *testing.B) {
 	}
 }
 
+func BenchmarkMarshal(b *testing.B) {
+	stu := &Student{Name: "sungn", Age: 24}
+	b.Run("reflect", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			json.Marshal((*plainStudent)(stu))
+		}
+	})
+	b.Run("generated", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			stu.MarshalJSON()
+		}
+	})
+}
+
+// 生成的代码与 encoding/json 的编解码结果相同
+func FuzzStudent(f *testing.F) {
+	f.Add(buf)
+	f.Add([]byte(`{"name":"a","AGE":-1,"Remark":[1,2,3]}`))
+	f.Add([]byte(`{"Name":"\u003c\ud83d\ude00","Age":2147483648}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var got, want Student
+		gerr := got.UnmarshalJSON(data)
+		werr := json.Unmarshal(data, (*plainStudent)(&want))
+		if (gerr == nil) != (werr == nil) {
+			t.Fatalf("Unmarshal(%s): error %v, encoding/json error %v", data, gerr, werr)
+		}
+		if werr != nil {
+			return
+		}
+		if got != want {
+			t.Fatalf("Unmarshal(%s): got %+v, want %+v", data, got, want)
+		}
+		out, _ := got.MarshalJSON()
+		if wout, _ := json.Marshal((*plainStudent)(&want)); !bytes.Equal(out, wout) {
+			t.Fatalf("Marshal:\n got %s\nwant %s", out, wout)
+		}
+	})
+}
+
+// studentPool 放回对象前没有清空，json 输入中缺少的字段会保留上一个使用者写入的值。
+// typedpool 要求提供 reset 函数，Get 到的对象总是干净的，也不再需要类型断言。
+var studentTypedPool = typedpool.New(func() *Student { return new(Student) }, func(s *Student) { *s = Student{} })
+
+func TestPoolReset(t *testing.T) {
+	noRemark := []byte(`{"Name":"sungn","Age":24}`)
+
+	stu := studentPool.Get().(*Student)
+	copy(stu.Remark[:], "secret")
+	studentPool.Put(stu)
+	stu = studentPool.Get().(*Student)
+	json.Unmarshal(noRemark, (*plainStudent)(stu))
+	// -race 模式下 sync.Pool 会随机丢弃对象，取到新对象时 Remark 自然是空的
+	t.Logf("sync.Pool remark: %q", bytes.TrimRight(stu.Remark[:], "\x00"))
+
+	stu = studentTypedPool.Get()
+	copy(stu.Remark[:], "secret")
+	studentTypedPool.Put(stu)
+	stu = studentTypedPool.Get()
+	json.Unmarshal(noRemark, (*plainStudent)(stu))
+	if stu.Remark[0] != 0 {
+		t.Fatalf("stale remark %q", bytes.TrimRight(stu.Remark[:], "\x00"))
+	}
+}
+
+func BenchmarkUnmarshalWithTypedPool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stu := studentTypedPool.Get()
+		json.Unmarshal(buf, (*plainStudent)(stu))
+		studentTypedPool.Put(stu)
+	}
+}
+
 // 在Go语言中五个引用类型变量,其他都是值类型: slice, map, channel, interface, func()
 // 由于结构体是值类型,在方法传递时希望传递结构体地址,可以使用时结构体指针完成
 // 可以结合new(T)函数创建结构体指针
@@ -72,5 +182,68 @@ func BenchmarkBufferWithpool(b *testing.B) {
 	}
 }
 
+// bufferPool 会保留任意大的 Buffer，这里超过 64KB 的 Buffer 直接丢弃
+var bufferTypedPool = typedpool.New(func() *bytes.Buffer { return new(bytes.Buffer) }, (*bytes.Buffer).Reset,
+	typedpool.MaxSize((*bytes.Buffer).Cap, 64<<10))
+
+func BenchmarkBufferWithTypedPool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		buf := bufferTypedPool.Get()
+		buf.Write(data)
+		bufferTypedPool.Put(buf)
+	}
+}
+
+// payloads 模拟 HTTP 请求体的尺寸：1KB 到 4MB，按对数均匀分布，小请求多、大请求少
+var payloads = func() []int {
+	sizes := make([]int, 1024)
+	x := uint32(1)
+	for i := range sizes {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		shift := 10 + x%13 // 1KB << [0, 12]
+		sizes[i] = 1<<shift + int(x>>8)%(1<<shift)
+		if sizes[i] > 4<<20 {
+			sizes[i] = 4 << 20
+		}
+	}
+	return sizes
+}()
+
+var payload = make([]byte, 4<<20)
+
+// 单个 bufferPool 处理不同尺寸的请求，池中的 Buffer 很快都增长到 4MB，
+// 每次 1KB 的请求也占着 4MB 的内存，waste-B/op 是每次请求容量超出所需的平均字节数
+func BenchmarkMixedBufferWithpool(b *testing.B) {
+	pool := sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	var waste int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		n := payloads[i%len(payloads)]
+		buf := pool.Get().(*bytes.Buffer)
+		buf.Write(payload[:n])
+		waste += buf.Cap() - n
+		buf.Reset()
+		pool.Put(buf)
+	}
+	b.ReportMetric(float64(waste)/float64(b.N), "waste-B/op")
+}
+
+// 分级的 bufpool 每个尺寸等级单独复用，浪费不超过请求尺寸本身
+func BenchmarkMixedSizeClassPool(b *testing.B) {
+	pool := bufpool.New(1<<10, 4<<20)
+	var waste int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		n := payloads[i%len(payloads)]
+		buf := pool.Get(n)
+		copy(buf, payload[:n])
+		waste += cap(buf) - n
+		pool.Put(buf)
+	}
+	b.ReportMetric(float64(waste)/float64(b.N), "waste-B/op")
+}
+
 // 参考fmt.Printf的源码
 // fmt.Printf 的调用是非常频繁的，利用 sync.Pool 复用 pp 对象能够极大地提升性能，减少内存占用，同时降低 GC 压力
@@ -0,0 +1,85 @@
+// Package timeout 把 timeout_test.go 中 timeout() 和 timeoutFirstPhase() 的经验整理为可复用的函数。
+//
+// dobadthing 泄漏的原因是：超时后没有接收方，工作协程永远阻塞在无缓冲的 done <- true 上。
+// RunWithTimeout 用容量为 1 的结果通道保证工作协程总能把结果放下并退出，
+// 同时把 ctx 传给任务，让任务在超时后可以尽早结束，而不是在后台空跑到底。
+//
+// RunTwoPhase 对应 do2phases：第一阶段可以在超时时被放弃；一旦第一阶段的结果被提交，
+// 就一定会等待第二阶段并返回其结果，不会出现先返回超时、再返回结果的"两次响应"。
+package timeout
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type result[T any] struct {
+	val T
+	err error
+}
+
+// RunWithTimeout 在新协程中运行 fn，ctx 取消或超时时立即返回 ctx.Err()。
+// 超时后 fn 仍会在后台运行到结束，但它的 ctx 已被取消，结果会被丢弃，协程不会泄漏。
+func RunWithTimeout[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 缓冲区为 1，即使调用方已经返回，发送也不会阻塞
+	done := make(chan result[T], 1)
+	go func() {
+		v, err := fn(ctx)
+		done <- result[T]{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.val, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// 第一阶段结果的状态，只能从 pending 变为 committed 或 timedOut 之一
+const (
+	pending int32 = iota
+	committed
+	timedOut
+)
+
+// RunTwoPhase 在新协程中运行 phase1，在 ctx 取消前完成时，把结果交给调用方所在的协程运行 phase2。
+//
+// "已提交"还是"已超时"由一次 CAS 决定：phase1 完成时尝试把状态从 pending 改为 committed，
+// 调用方在 ctx 取消时尝试改为 timedOut，先成功的一方生效。
+// 不能依靠无缓冲通道加 select default 来判断：调用方还没有执行到 select 时发送就会失败，
+// phase1 明明已经完成却返回超时，ctx 没有截止时间时调用方甚至会永远阻塞。
+// 提交成功后结果放入容量为 1 的通道，调用方即使在 ctx 取消时 CAS 失败，也会等到这个结果。
+//
+// 提交成功后，phase2 使用不受 ctx 取消影响的 context 运行，调用方一定会得到 phase2 的结果而不是超时，
+// 因此 phase2 应该是发送响应这类必须完成的短操作。
+func RunTwoPhase[T, R any](ctx context.Context, phase1 func(ctx context.Context) (T, error), phase2 func(ctx context.Context, v T) (R, error)) (R, error) {
+	ctx1, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var state atomic.Int32
+	commit := make(chan result[T], 1)
+	go func() {
+		v, err := phase1(ctx1)
+		if state.CompareAndSwap(pending, committed) {
+			commit <- result[T]{v, err}
+		}
+		// 否则调用方已经返回超时，放弃结果
+	}()
+	var zero R
+	var r result[T]
+	select {
+	case r = <-commit:
+	case <-ctx.Done():
+		if state.CompareAndSwap(pending, timedOut) {
+			return zero, ctx.Err()
+		}
+		// phase1 抢先提交了，结果马上就会放入通道
+		r = <-commit
+	}
+	if r.err != nil {
+		return zero, r.err
+	}
+	return phase2(context.WithoutCancel(ctx), r.val)
+}
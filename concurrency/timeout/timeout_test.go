@@ -0,0 +1,117 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitGoroutines 等待协程数回落到 n 以内，超过 1s 仍未回落则认为有泄漏
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d > %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	v, err := RunWithTimeout(context.Background(), func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	if v != 42 || err != nil {
+		t.Fatalf("got %d %v", v, err)
+	}
+	want := errors.New("failed")
+	if _, err := RunWithTimeout(context.Background(), func(ctx context.Context) (int, error) {
+		return 0, want
+	}); err != want {
+		t.Fatalf("got %v, want %v", err, want)
+	}
+}
+
+// 与 TestBadTimeout 相同：1000 次超时，任务本身不理会 ctx，但协程最终都能退出
+func TestRunWithTimeoutNoLeak(t *testing.T) {
+	base := runtime.NumGoroutine()
+	for i := 0; i < 1000; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_, err := RunWithTimeout(ctx, func(ctx context.Context) (bool, error) {
+			time.Sleep(50 * time.Millisecond)
+			return true, nil
+		})
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Fatalf("got %v, want DeadlineExceeded", err)
+		}
+	}
+	waitGoroutines(t, base)
+}
+
+func TestRunTwoPhase(t *testing.T) {
+	r, err := RunTwoPhase(context.Background(),
+		func(ctx context.Context) (int, error) { return 21, nil },
+		func(ctx context.Context, v int) (int, error) { return v * 2, nil })
+	if r != 42 || err != nil {
+		t.Fatalf("got %d %v", r, err)
+	}
+}
+
+// 第一阶段立即完成时，无论调用方是否已经在等待，结果都会被提交。
+// 没有截止时间的 ctx 下不能出现超时，也不能永远阻塞
+func TestRunTwoPhaseFastPhase1(t *testing.T) {
+	for i := 0; i < 10000; i++ {
+		r, err := RunTwoPhase(context.Background(),
+			func(ctx context.Context) (int, error) { return i, nil },
+			func(ctx context.Context, v int) (int, error) { return v + 1, nil })
+		if r != i+1 || err != nil {
+			t.Fatalf("run %d: got %d %v", i, r, err)
+		}
+	}
+}
+
+// 第一阶段超时：返回超时，第二阶段永远不会运行
+func TestRunTwoPhaseTimeout(t *testing.T) {
+	base := runtime.NumGoroutine()
+	var phase2 atomic.Int32
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_, err := RunTwoPhase(ctx,
+			func(ctx context.Context) (int, error) {
+				time.Sleep(20 * time.Millisecond)
+				return 1, nil
+			},
+			func(ctx context.Context, v int) (int, error) {
+				phase2.Add(1)
+				return v, nil
+			})
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Fatalf("got %v, want DeadlineExceeded", err)
+		}
+	}
+	waitGoroutines(t, base)
+	if n := phase2.Load(); n != 0 {
+		t.Fatalf("phase2 ran %d times after timeout", n)
+	}
+}
+
+// 第一阶段提交后，即使第二阶段期间超时，也一定返回第二阶段的结果
+func TestRunTwoPhaseCommitted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r, err := RunTwoPhase(ctx,
+		func(ctx context.Context) (int, error) { return 1, nil },
+		func(ctx context.Context, v int) (int, error) {
+			time.Sleep(50 * time.Millisecond)
+			return v + 1, ctx.Err()
+		})
+	if r != 2 || err != nil {
+		t.Fatalf("got %d %v, want phase2 result", r, err)
+	}
+}
@@ -2,9 +2,12 @@ package concurrency
 
 import (
 	"fmt"
+	"os"
 	"runtime"
 	"testing"
 	"time"
+
+	"highPerformance/pprof/rtsample"
 )
 
 func dobadthing(done chan bool) {
@@ -40,13 +43,17 @@ func TestTimeout(t *testing.T) {
 	fmt.Println(timeout(dobadthing))
 }
 
+// 运行期间每 100ms 采样一次 runtime/metrics，通过 goroutines 的峰值和 last 可以看出协程是否随任务结束而回落
 func test(t *testing.T, f func(chan bool)) {
 	t.Helper()
-	for i := 0; i < 1000; i++ {
-		timeout(f)
-	}
-	time.Sleep(2 * time.Second)
+	series := rtsample.Run(100*time.Millisecond, func() {
+		for i := 0; i < 1000; i++ {
+			timeout(f)
+		}
+		time.Sleep(2 * time.Second)
+	})
 	fmt.Println(runtime.NumGoroutine())
+	series.Summary(os.Stdout)
 }
 
 // 最终程序中存在着 1002 个子协程，说明即使是函数执行完成，协程也没有正常退出。
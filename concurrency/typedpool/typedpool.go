@@ -0,0 +1,131 @@
+// Package typedpool 在 sync.Pool 之上提供类型安全的对象池。
+// syncpool_test.go 中直接使用 sync.Pool 有几个问题：
+//   - Get 的结果需要类型断言
+//   - studentPool 放回对象前没有清空 Remark，下一次 json.Unmarshal 时输入中没有的字段会保留上一次的值
+//   - bufferPool 会保留任意大的 bytes.Buffer，偶尔一次写入 100MB，这块内存就会一直留在池中
+//   - 对象 Put 回池中之后如果还在被使用，会和下一次 Get 到它的协程产生难以排查的数据竞争
+//
+// Pool 要求提供 reset 函数，可以设置超过一定大小的对象直接丢弃，统计命中率，
+// 调试模式下会在 Put 时把对象"下毒"，在下一次 Get 时检查毒是否还在，以发现释放后继续使用的问题。
+//
+// T 应当是指针类型，否则放入 sync.Pool 时转换为 interface{} 本身就需要分配内存。
+package typedpool
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool 是类型安全的对象池，需要使用 New 创建
+type Pool[T any] struct {
+	p     sync.Pool
+	new   func() T
+	reset func(T)
+
+	size    func(T) int
+	maxSize int
+
+	poison func(T)
+	check  func(T) bool
+
+	gets, puts, news, dropped atomic.Int64
+}
+
+// Option 是 Pool 的可选配置
+type Option[T any] func(*Pool[T])
+
+// MaxSize 设置可以放回池中的对象的最大尺寸，size 返回对象的尺寸（例如 bytes.Buffer 的 Cap），
+// 超过 max 的对象在 Put 时直接丢弃，交给 GC 回收
+func MaxSize[T any](size func(T) int, max int) Option[T] {
+	return func(p *Pool[T]) {
+		p.size, p.maxSize = size, max
+	}
+}
+
+// Debug 开启调试模式：Put 时在 reset 之后调用 poison 把对象填充为特殊的值，
+// 下一次 Get 到这个对象时调用 check，返回 false 说明对象在 Put 之后被修改过，Get 会 panic。
+// check 为 nil 时只下毒不检查，释放后继续读取对象的代码会读到明显异常的值。
+func Debug[T any](poison func(T), check func(T) bool) Option[T] {
+	return func(p *Pool[T]) {
+		p.poison, p.check = poison, check
+	}
+}
+
+// New 创建对象池，newFn 用于创建新对象，reset 在对象放回池中时清空对象，二者都不能为 nil
+func New[T any](newFn func() T, reset func(T), opts ...Option[T]) *Pool[T] {
+	if newFn == nil || reset == nil {
+		panic("typedpool: New requires non-nil new and reset functions")
+	}
+	p := &Pool[T]{new: newFn, reset: reset}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Get 从池中取出一个对象，池为空时创建新对象
+func (p *Pool[T]) Get() T {
+	p.gets.Add(1)
+	v, ok := p.p.Get().(T)
+	if !ok {
+		p.news.Add(1)
+		return p.new()
+	}
+	if p.poison != nil {
+		if p.check != nil && !p.check(v) {
+			panic(fmt.Sprintf("typedpool: %T was modified after Put (use after release)", v))
+		}
+		// 毒是 reset 之后下的，需要再清空一次
+		p.reset(v)
+	}
+	return v
+}
+
+// Put 清空对象并放回池中，尺寸超过上限的对象会被丢弃。Put 之后调用方不能再使用该对象。
+func (p *Pool[T]) Put(v T) {
+	p.puts.Add(1)
+	if p.size != nil && p.size(v) > p.maxSize {
+		p.dropped.Add(1)
+		return
+	}
+	p.reset(v)
+	if p.poison != nil {
+		p.poison(v)
+	}
+	p.p.Put(v)
+}
+
+// Stats 是对象池的统计信息
+type Stats struct {
+	Gets    int64 // Get 的次数
+	Puts    int64 // Put 的次数
+	News    int64 // 池为空、创建新对象的次数，即未命中次数
+	Dropped int64 // 因为尺寸超过上限而丢弃的次数
+}
+
+// Hits 返回命中（从池中取到对象）的次数
+func (s Stats) Hits() int64 { return s.Gets - s.News }
+
+// HitRate 返回命中率
+func (s Stats) HitRate() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Hits()) / float64(s.Gets)
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("gets %d, hits %d (%.1f%%), news %d, puts %d, dropped %d",
+		s.Gets, s.Hits(), s.HitRate()*100, s.News, s.Puts, s.Dropped)
+}
+
+// Stats 返回统计信息
+func (p *Pool[T]) Stats() Stats {
+	return Stats{
+		Gets:    p.gets.Load(),
+		Puts:    p.puts.Load(),
+		News:    p.news.Load(),
+		Dropped: p.dropped.Load(),
+	}
+}
@@ -0,0 +1,124 @@
+package typedpool
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newBufferPool(opts ...Option[*bytes.Buffer]) *Pool[*bytes.Buffer] {
+	return New(func() *bytes.Buffer { return new(bytes.Buffer) }, (*bytes.Buffer).Reset, opts...)
+}
+
+func TestPool(t *testing.T) {
+	p := newBufferPool()
+	b := p.Get()
+	b.WriteString("hello")
+	p.Put(b)
+	// -race 模式下 sync.Pool 会随机丢弃对象，这里只检查取到的对象已经被清空
+	for i := 0; i < 10; i++ {
+		b := p.Get()
+		if b.Len() != 0 {
+			t.Fatalf("got dirty buffer %q", b.String())
+		}
+		b.WriteString("world")
+		p.Put(b)
+	}
+	s := p.Stats()
+	if s.Gets != 11 || s.Puts != 11 || s.Hits()+s.News != s.Gets {
+		t.Fatalf("unexpected stats: %v", s)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	p := newBufferPool(MaxSize((*bytes.Buffer).Cap, 1024))
+	small, large := p.Get(), p.Get()
+	small.WriteString("x")
+	large.Write(make([]byte, 4096))
+	p.Put(small)
+	p.Put(large)
+	if s := p.Stats(); s.Dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", s.Dropped)
+	}
+	for i := 0; i < 10; i++ {
+		if b := p.Get(); b == large {
+			t.Fatal("oversized buffer was retained")
+		}
+	}
+}
+
+type object struct {
+	data [16]byte
+}
+
+const poisonByte = 0xde
+
+func poison(o *object) {
+	for i := range o.data {
+		o.data[i] = poisonByte
+	}
+}
+
+func poisoned(o *object) bool {
+	for _, b := range o.data {
+		if b != poisonByte {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDebugUseAfterPut(t *testing.T) {
+	p := New(func() *object { return new(object) }, func(o *object) { *o = object{} }, Debug(poison, poisoned))
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "use after release") {
+			t.Fatalf("expected use-after-release panic, got %v", r)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		o := p.Get()
+		if o.data[0] != 0 {
+			t.Fatal("poison was not cleared on Get")
+		}
+		p.Put(o)
+		o.data[0] = 1 // 释放后继续写入
+	}
+}
+
+func TestNewRequiresReset(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	New[*object](func() *object { return new(object) }, nil)
+}
+
+func BenchmarkPool(b *testing.B) {
+	data := make([]byte, 1024)
+	b.Run("sync.Pool", func(b *testing.B) {
+		var p = bytesPool()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := p.Get().(*bytes.Buffer)
+			buf.Write(data)
+			buf.Reset()
+			p.Put(buf)
+		}
+	})
+	b.Run("typedpool", func(b *testing.B) {
+		p := newBufferPool(MaxSize((*bytes.Buffer).Cap, 64<<10))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := p.Get()
+			buf.Write(data)
+			p.Put(buf)
+		}
+	})
+}
+
+func bytesPool() *sync.Pool {
+	return &sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+}
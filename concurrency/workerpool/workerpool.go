@@ -0,0 +1,246 @@
+// Package workerpool 提供固定数量工作协程、有界队列的泛型协程池。
+// goroutine_test.go 中的 do/sendTasks 只有一个写死的消费者，这里把它扩展为：
+// 可配置的协程数和队列长度、提交后可获取结果和错误、支持 context 取消、
+// 单个任务 panic 不影响其他任务、优雅关闭（Shutdown 处理完队列中的任务）和立即停止（Stop 放弃队列中的任务），
+// 以及排队数、执行中和已完成的任务计数。
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrClosed 表示协程池已经关闭，不再接受新任务
+	ErrClosed = errors.New("workerpool: pool is closed")
+	// ErrStopped 表示任务在排队时协程池被 Stop，任务没有被执行
+	ErrStopped = errors.New("workerpool: pool is stopped")
+)
+
+// PanicError 包装任务执行过程中的 panic
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("workerpool: task panicked: %v", e.Value)
+}
+
+// Task 是提交给协程池的任务，ctx 在提交方的 ctx 取消或协程池 Stop 时被取消
+type Task[T any] func(ctx context.Context) (T, error)
+
+// Future 是已提交任务的结果
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Done 返回一个在任务结束（完成、失败或被放弃）时关闭的 channel
+func (f *Future[T]) Done() <-chan struct{} { return f.done }
+
+// Wait 等待任务结束并返回结果，ctx 取消时返回 ctx.Err()，但任务本身不会因此被取消
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+type job[T any] struct {
+	ctx  context.Context
+	task Task[T]
+	f    *Future[T]
+}
+
+func (j *job[T]) finish(v T, err error) {
+	j.f.val, j.f.err = v, err
+	close(j.f.done)
+}
+
+// Stats 是协程池的运行计数
+type Stats struct {
+	Workers int
+	// Queued 为排队中的任务数，Active 为执行中的任务数
+	Queued int64
+	Active int64
+	// Completed 为执行结束的任务数，其中 Failed 个返回了错误（包括 panic），Panicked 个发生了 panic
+	Completed int64
+	Failed    int64
+	Panicked  int64
+	// Abandoned 为因 Stop 或提交方 ctx 取消而未执行的任务数
+	Abandoned int64
+}
+
+// Pool 是泛型协程池，T 为任务结果的类型
+type Pool[T any] struct {
+	workers int
+	tasks   chan *job[T]
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	// mu 保证关闭 tasks 时没有正在发送的 Submit，向已关闭的 channel 发送数据会 panic
+	mu     sync.RWMutex
+	closed bool
+	// closing 在获取 mu 写锁之前关闭，让阻塞在 Submit 中的提交方退出并释放读锁
+	closing     chan struct{}
+	closingOnce sync.Once
+
+	queued, active, completed, failed, panicked, abandoned atomic.Int64
+}
+
+// New 创建有 workers 个工作协程、队列长度为 queueSize 的协程池。
+// queueSize 为 0 时 Submit 会一直阻塞到有空闲的工作协程接收任务。
+func New[T any](workers, queueSize int) *Pool[T] {
+	if workers <= 0 {
+		panic("workerpool: workers must be positive")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T]{
+		workers: workers,
+		tasks:   make(chan *job[T], queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+// Submit 提交任务，队列已满时阻塞，直到任务入队、ctx 取消或协程池关闭。
+// ctx 同时会传给任务，任务开始执行前 ctx 已取消的，任务不会执行，Future 返回 ctx.Err()。
+func (p *Pool[T]) Submit(ctx context.Context, task Task[T]) (*Future[T], error) {
+	j := &job[T]{ctx: ctx, task: task, f: &Future[T]{done: make(chan struct{})}}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	// 先计数再入队，否则工作协程可能在计数之前就取走任务，导致 Queued 短暂为负
+	p.queued.Add(1)
+	select {
+	case p.tasks <- j:
+		return j.f, nil
+	case <-ctx.Done():
+		p.queued.Add(-1)
+		return nil, ctx.Err()
+	case <-p.closing:
+		p.queued.Add(-1)
+		return nil, ErrClosed
+	}
+}
+
+// SubmitWait 提交任务并等待其结果
+func (p *Pool[T]) SubmitWait(ctx context.Context, task Task[T]) (T, error) {
+	f, err := p.Submit(ctx, task)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return f.Wait(ctx)
+}
+
+// Shutdown 停止接受新任务，等待队列中和执行中的任务全部完成。
+// ctx 在此之前取消时，剩余的任务会像 Stop 一样被放弃，返回 ctx.Err()。
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.close()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Stop 停止接受新任务，取消执行中任务的 ctx，放弃队列中的任务（Future 返回 ErrStopped），
+// 并等待所有工作协程退出
+func (p *Pool[T]) Stop() {
+	p.cancel()
+	p.close()
+	p.wg.Wait()
+}
+
+func (p *Pool[T]) close() {
+	// 队列已满时 Submit 持有读锁阻塞在发送上，先通知它们退出，否则这里拿不到写锁
+	p.closingOnce.Do(func() { close(p.closing) })
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+}
+
+// Stats 返回当前的运行计数
+func (p *Pool[T]) Stats() Stats {
+	return Stats{
+		Workers:   p.workers,
+		Queued:    p.queued.Load(),
+		Active:    p.active.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Panicked:  p.panicked.Load(),
+		Abandoned: p.abandoned.Load(),
+	}
+}
+
+func (p *Pool[T]) worker() {
+	defer p.wg.Done()
+	for j := range p.tasks {
+		p.queued.Add(-1)
+		var zero T
+		if p.ctx.Err() != nil {
+			p.abandoned.Add(1)
+			j.finish(zero, ErrStopped)
+			continue
+		}
+		if err := j.ctx.Err(); err != nil {
+			p.abandoned.Add(1)
+			j.finish(zero, err)
+			continue
+		}
+		p.active.Add(1)
+		v, err := p.run(j)
+		p.active.Add(-1)
+		p.completed.Add(1)
+		if err != nil {
+			p.failed.Add(1)
+		}
+		j.finish(v, err)
+	}
+}
+
+func (p *Pool[T]) run(j *job[T]) (v T, err error) {
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+	defer func() {
+		if r := recover(); r != nil {
+			p.panicked.Add(1)
+			var zero T
+			v, err = zero, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return j.task(ctx)
+}
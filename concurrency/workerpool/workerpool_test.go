@@ -0,0 +1,170 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func square(n int) Task[int] {
+	return func(ctx context.Context) (int, error) {
+		return n * n, nil
+	}
+}
+
+func TestSubmitWait(t *testing.T) {
+	p := New[int](4, 10)
+	defer p.Stop()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := p.SubmitWait(context.Background(), square(i))
+			if err != nil || v != i*i {
+				t.Errorf("got %d %v, want %d", v, err, i*i)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if s := p.Stats(); s.Completed != 100 || s.Queued != 0 || s.Active != 0 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	p := New[int](1, 0)
+	defer p.Stop()
+	_, err := p.SubmitWait(context.Background(), func(ctx context.Context) (int, error) {
+		panic("boom")
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("got %v, want PanicError", err)
+	}
+	// panic 之后工作协程仍然可用
+	if v, err := p.SubmitWait(context.Background(), square(3)); v != 9 || err != nil {
+		t.Fatalf("got %d %v", v, err)
+	}
+	if s := p.Stats(); s.Panicked != 1 || s.Failed != 1 || s.Completed != 2 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	p := New[int](2, 100)
+	var done atomic.Int32
+	var futures []*Future[int]
+	for i := 0; i < 50; i++ {
+		f, err := p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		futures = append(futures, f)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if done.Load() != 50 {
+		t.Fatalf("got %d finished tasks, want 50", done.Load())
+	}
+	for _, f := range futures {
+		if _, err := f.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.Submit(context.Background(), square(1)); err != ErrClosed {
+		t.Fatalf("got %v, want ErrClosed", err)
+	}
+}
+
+func TestStopAbandons(t *testing.T) {
+	p := New[int](1, 10)
+	started := make(chan struct{})
+	running, _ := p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued, _ := p.Submit(context.Background(), square(2))
+	p.Stop()
+	if _, err := running.Wait(context.Background()); err != context.Canceled {
+		t.Fatalf("running task: got %v, want context.Canceled", err)
+	}
+	if _, err := queued.Wait(context.Background()); err != ErrStopped {
+		t.Fatalf("queued task: got %v, want ErrStopped", err)
+	}
+	if s := p.Stats(); s.Abandoned != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestSubmitCancel(t *testing.T) {
+	p := New[int](1, 0)
+	defer p.Stop()
+	block := make(chan struct{})
+	defer close(block)
+	p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+		<-block
+		return 0, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(ctx, square(1)); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	p := New[int](1, 0)
+	p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+}
+
+// 队列已满时阻塞在 Submit 中的提交方不能让 Shutdown 拿不到写锁而卡住
+func TestShutdownBlockedSubmit(t *testing.T) {
+	p := New[int](1, 0)
+	p.Submit(context.Background(), func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	submitted := make(chan error, 1)
+	go func() {
+		_, err := p.Submit(context.Background(), square(1))
+		submitted <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		done <- p.Shutdown(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("Shutdown: got %v, want DeadlineExceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown blocked by a pending Submit")
+	}
+	if err := <-submitted; err != ErrClosed {
+		t.Fatalf("Submit: got %v, want ErrClosed", err)
+	}
+}
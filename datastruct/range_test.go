@@ -3,6 +3,8 @@ package datastruct
 import (
 	"fmt"
 	"testing"
+
+	"highPerformance/pprof/gcdiag"
 )
 
 // 变量 words 在循环开始前，仅会计算一次，如果在循环中修改切片的长度不会改变本次循环的次数
@@ -75,6 +77,11 @@ func BenchmarkRangeIntSlice(b *testing.B) {
 	}
 }
 
+// 两种写法的边界检查都被编译器消除了，循环体内只剩下一次内存读取
+func TestBoundsCheck(t *testing.T) {
+	gcdiag.AssertNoBoundsCheck(t, ".", "BenchmarkForIntSlice", "BenchmarkRangeIntSlice")
+}
+
 // 与 for 不同的是，range 对每个迭代值都创建了一个拷贝。因此如果每次迭代的值内存占用很小的情况下，for 和 range 的性能几乎没有差异，
 // 但是如果每个迭代值内存占用很大，例如上面的例子中，每个结构体需要占据 4KB 的内存，这种情况下差距就非常明显了
 type Item struct {
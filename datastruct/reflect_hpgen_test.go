@@ -0,0 +1,91 @@
+// Code generated by hpgen. DO NOT EDIT.
+
+package datastruct
+
+import (
+	"highPerformance/serialize/hpjson"
+)
+
+// MarshalJSON 实现 json.Marshaler，输出与 encoding/json 相同
+func (v *Config) MarshalJSON() ([]byte, error) {
+	return v.AppendJSON(make([]byte, 0, 119))
+}
+
+// AppendJSON 把 v 编码为 JSON 对象追加到 dst
+func (v *Config) AppendJSON(dst []byte) ([]byte, error) {
+	n := len(dst)
+	dst = append(dst, `,"server-name":`...)
+	dst = hpjson.AppendString(dst, v.Name)
+	dst = append(dst, `,"server-ip":`...)
+	dst = hpjson.AppendString(dst, v.IP)
+	dst = append(dst, `,"server-url":`...)
+	dst = hpjson.AppendString(dst, v.URL)
+	dst = append(dst, `,"timeout":`...)
+	dst = hpjson.AppendString(dst, v.Timeout)
+	if len(dst) == n {
+		dst = append(dst, '{')
+	} else {
+		dst[n] = '{'
+	}
+	return append(dst, '}'), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，接受的输入和解码结果与 encoding/json 相同
+func (v *Config) UnmarshalJSON(data []byte) error {
+	var l hpjson.Lexer
+	l.Reset(data)
+	v.DecodeJSON(&l)
+	return l.End()
+}
+
+// DecodeJSON 从 l 读取一个 JSON 对象解码到 v，错误记录在 l 中
+func (v *Config) DecodeJSON(l *hpjson.Lexer) {
+	if l.Null() {
+		return
+	}
+	l.BeginObject()
+	for l.NextKey() {
+		f := -1
+		switch string(l.Key()) {
+		case "server-name":
+			f = 0
+		case "server-ip":
+			f = 1
+		case "server-url":
+			f = 2
+		case "timeout":
+			f = 3
+		default:
+			switch string(l.FoldedKey()) {
+			case "SERVER-NAME":
+				f = 0
+			case "SERVER-IP":
+				f = 1
+			case "SERVER-URL":
+				f = 2
+			case "TIMEOUT":
+				f = 3
+			}
+		}
+		switch f {
+		case 0:
+			if r, ok := l.String("string"); ok {
+				v.Name = r
+			}
+		case 1:
+			if r, ok := l.String("string"); ok {
+				v.IP = r
+			}
+		case 2:
+			if r, ok := l.String("string"); ok {
+				v.URL = r
+			}
+		case 3:
+			if r, ok := l.String("string"); ok {
+				v.Timeout = r
+			}
+		default:
+			l.Skip()
+		}
+	}
+}
@@ -15,13 +15,21 @@ One example is the database/sql conversion routines: by using reflect.Indirect,
 package datastruct
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+
+	"highPerformance/serialize/hpbin"
 )
 
+// Config 带有 hpgen 注释，reflect_hpgen_test.go 中生成了不使用反射的 MarshalJSON 和 UnmarshalJSON
+//
+//go:generate go run highPerformance/cmd/hpgen .
+//hpgen:json
 type Config struct {
 	Name    string `json:"server-name"` // CONFIG_SERVER_NAME
 	IP      string `json:"server-ip"`   // CONFIG_SERVER_IP
@@ -121,3 +129,89 @@ func BenchmarkReflect_FieldByNameCacheSet(b *testing.B) {
 		ins.Field(cache["Timeout"]).SetString("timeout")
 	}
 }
+
+// plainConfig 没有生成的方法，encoding/json 使用反射处理它
+type plainConfig Config
+
+// AppendBinary 追加 c 的 hpbin 编码，字段按声明顺序排列
+func (c *Config) AppendBinary(dst []byte) ([]byte, error) {
+	dst = hpbin.AppendString(dst, c.Name)
+	dst = hpbin.AppendString(dst, c.IP)
+	dst = hpbin.AppendString(dst, c.URL)
+	return hpbin.AppendString(dst, c.Timeout), nil
+}
+
+// UnmarshalBinary 实现 encoding.BinaryUnmarshaler，字段与上一次解码的值相同时不分配内存
+func (c *Config) UnmarshalBinary(data []byte) error {
+	var r hpbin.Reader
+	r.Reset(data)
+	c.Name = r.String(c.Name)
+	c.IP = r.String(c.IP)
+	c.URL = r.String(c.URL)
+	c.Timeout = r.String(c.Timeout)
+	return r.End()
+}
+
+var configJSON = []byte(`{"server-name":"global_server","server-ip":"10.0.0.1","server-url":"sungn.com","timeout":"5s"}`)
+
+func BenchmarkConfigUnmarshal(b *testing.B) {
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var c plainConfig
+			json.Unmarshal(configJSON, &c)
+		}
+	})
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var c Config
+			c.UnmarshalJSON(configJSON)
+		}
+	})
+	var c Config
+	json.Unmarshal(configJSON, (*plainConfig)(&c))
+	data, _ := c.AppendBinary(nil)
+	b.Run("binary", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var c Config
+			c.UnmarshalBinary(data)
+		}
+	})
+	// 配置在多次加载之间很少变化，解码到上一次的对象中时字符串不需要重新分配
+	b.Run("binary-reuse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.UnmarshalBinary(data)
+		}
+	})
+}
+
+func FuzzConfig(f *testing.F) {
+	f.Add(configJSON)
+	f.Add([]byte(`{"SERVER-NAME":"a","timeout":null,"other":[1,{"x":2}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var got, want Config
+		gerr := got.UnmarshalJSON(data)
+		werr := json.Unmarshal(data, (*plainConfig)(&want))
+		if (gerr == nil) != (werr == nil) {
+			t.Fatalf("Unmarshal(%s): error %v, encoding/json error %v", data, gerr, werr)
+		}
+		if werr != nil {
+			return
+		}
+		if got != want {
+			t.Fatalf("Unmarshal(%s): got %+v, want %+v", data, got, want)
+		}
+		out, _ := got.MarshalJSON()
+		if wout, _ := json.Marshal((*plainConfig)(&want)); !bytes.Equal(out, wout) {
+			t.Fatalf("Marshal:\n got %s\nwant %s", out, wout)
+		}
+		bin, _ := got.AppendBinary(nil)
+		var back Config
+		if err := back.UnmarshalBinary(bin); err != nil || back != got {
+			t.Fatalf("binary round trip: got %+v %v, want %+v", back, err, got)
+		}
+	})
+}
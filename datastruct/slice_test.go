@@ -3,9 +3,12 @@ package datastruct
 import (
 	"fmt"
 	"math/rand"
+	"os"
 	"runtime"
 	"testing"
 	"time"
+
+	"highPerformance/pprof/memsnap"
 )
 
 func PrintLenCap(nums []int) {
@@ -64,8 +67,11 @@ func printMem(t *testing.T) {
 	t.Logf("%.2f MB", float64(rtm.Alloc)/1024./1024.)
 }
 
+// printMem 只能看到总量，memsnap 对比前后两次快照，能定位到是哪个分配点留住了内存：
+// TestLastCharsBySlice 会报告 generateWithCap retains 100 MB <- testLastChars <- TestLastCharsBySlice
 func testLastChars(t *testing.T, f func([]int) []int) {
 	t.Helper()
+	before := memsnap.Take()
 	ans := make([][]int, 0)
 	for k := 0; k < 100; k++ {
 		origin := generateWithCap(128 * 1024) // 1M
@@ -73,7 +79,8 @@ func testLastChars(t *testing.T, f func([]int) []int) {
 		runtime.GC()
 	}
 	printMem(t)
-	_ = ans
+	memsnap.Compare(before, memsnap.Take()).WriteTop(os.Stdout, 3)
+	runtime.KeepAlive(ans)
 }
 
 func TestLastCharsBySlice(t *testing.T) { testLastChars(t, lastNumsBySlice) }
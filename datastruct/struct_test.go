@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"unsafe"
+
+	"highPerformance/pprof/gcdiag"
 )
 
 // 空结构体不占据内存空间，因此被广泛作为各种场景下的占位符使用。
@@ -73,6 +75,25 @@ func (d Door) Close() {
 // 在对象频繁创建和删除的场景下，传递指针导致的 GC 开销可能会严重影响性能。
 // 一般情况下，对于需要修改原对象值，或占用内存比较大的结构体，选择传指针。对于只读的占用内存较小的结构体，直接传值能够获得更好的性能。
 
+func newArgs(a, b int) Args {
+	return Args{a: a, b: b}
+}
+
+func newArgsPtr(a, b int) *Args {
+	return &Args{a: a, b: b}
+}
+
+func sumArgs(args Args) int {
+	return args.a + args.b
+}
+
+// 用 gcdiag 把上面的结论变成断言：传值的版本不逃逸，返回指针的版本逃逸到堆上。
+// 热点路径上的函数被改动后如果开始逃逸，这个测试会失败
+func TestEscape(t *testing.T) {
+	gcdiag.AssertNoEscape(t, ".", "newArgs", "sumArgs", "Set.Has", "Set.del")
+	gcdiag.AssertEscape(t, ".", "newArgsPtr")
+}
+
 // 因此，在声明全局变量时，如果能够确定为常量，尽量使用 const 而非 var，这样很多运算在编译器即可执行。
 
 // 我们可以在源代码中，定义全局常量 debug，值设置为 false，在需要增加调试代码的地方，使用条件语句 if debug 包裹
@@ -1,5 +1,5 @@
 module highPerformance
 
-go 1.16
+go 1.21
 
 require github.com/pkg/profile v1.6.0
@@ -3,6 +3,8 @@ package highprog
 import (
 	"fmt"
 	"testing"
+
+	"highPerformance/pprof/gcdiag"
 )
 
 func TrimSpace(s []byte) []byte {
@@ -20,3 +22,9 @@ func TestEmpSlice(t *testing.T) {
 	var spac string = "hello world   ,  sungn!"
 	fmt.Println(string(TrimSpace([]byte(spac))))
 }
+
+// TrimSpace 足够小，可以被内联到调用方；range 遍历和复用底层数组的 append 也不会留下边界检查
+func TestTrimSpaceOptimized(t *testing.T) {
+	gcdiag.AssertInlinable(t, ".", "TrimSpace")
+	gcdiag.AssertNoBoundsCheck(t, ".", "TrimSpace")
+}
@@ -0,0 +1,102 @@
+package gcdiag
+
+import (
+	"sync"
+	"testing"
+)
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]*Package)
+)
+
+// compileCached 以 -m=2 和 -d=ssa/check_bce/debug=1 编译 pkg（包含测试文件），同一个包只编译一次
+func compileCached(pkg string) (*Package, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if p, ok := cache[pkg]; ok {
+		return p, nil
+	}
+	p, err := Compile(pkg, true, "-m=2", "-d=ssa/check_bce/debug=1")
+	if err != nil {
+		return nil, err
+	}
+	cache[pkg] = p
+	return p, nil
+}
+
+// AssertNoEscape 断言 pkg 中的函数 funcs 不会产生堆分配：
+// 没有 escapes to heap、moved to heap，参数也不会泄漏到堆上。
+// pkg 可以是导入路径，也可以是相对当前目录的路径，测试中通常传 "."。
+func AssertNoEscape(t testing.TB, pkg string, funcs ...string) {
+	t.Helper()
+	p, err := compileCached(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range funcs {
+		f := p.Func(name)
+		if f == nil {
+			t.Errorf("%s: function %s not found", pkg, name)
+			continue
+		}
+		for _, d := range f.Escapes() {
+			t.Errorf("%s:%d:%d: %s: %s", d.File, d.Line, d.Col, name, d.Message)
+		}
+	}
+}
+
+// AssertEscape 断言 pkg 中的函数 fn 至少产生一次堆分配，用于确认基准中的对照组确实逃逸了
+func AssertEscape(t testing.TB, pkg string, fn string) {
+	t.Helper()
+	p, err := compileCached(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Func(fn)
+	if f == nil {
+		t.Fatalf("%s: function %s not found", pkg, fn)
+	}
+	if len(f.Escapes()) == 0 {
+		t.Errorf("%s: expected %s to allocate on heap", pkg, fn)
+	}
+}
+
+// AssertNoBoundsCheck 断言 pkg 中的函数 funcs 内没有剩余的边界检查
+func AssertNoBoundsCheck(t testing.TB, pkg string, funcs ...string) {
+	t.Helper()
+	p, err := compileCached(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range funcs {
+		f := p.Func(name)
+		if f == nil {
+			t.Errorf("%s: function %s not found", pkg, name)
+			continue
+		}
+		for _, d := range f.BoundsChecks() {
+			t.Errorf("%s:%d:%d: %s: bounds check %s", d.File, d.Line, d.Col, name, d.Subject)
+		}
+	}
+}
+
+// AssertInlinable 断言 pkg 中的函数 funcs 都可以被内联
+func AssertInlinable(t testing.TB, pkg string, funcs ...string) {
+	t.Helper()
+	p, err := compileCached(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range funcs {
+		f := p.Func(name)
+		if f == nil {
+			t.Errorf("%s: function %s not found", pkg, name)
+			continue
+		}
+		if ok, _ := f.Inlinable(); !ok {
+			d, _ := f.InlineFailure()
+			t.Errorf("%s: %s is not inlinable: %s", pkg, name, d.Reason)
+		}
+	}
+}
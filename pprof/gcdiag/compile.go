@@ -0,0 +1,211 @@
+package gcdiag
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Func 是一个函数的全部诊断
+type Func struct {
+	Name  string
+	File  string
+	Line  int
+	Diags []Diag
+}
+
+// Escapes 返回函数中导致堆分配的诊断
+func (f *Func) Escapes() []Diag {
+	var ds []Diag
+	for _, d := range f.Diags {
+		if d.HeapAlloc() {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// Inlinable 报告函数本身能否被内联，以及内联代价（-m=2 时才有）
+func (f *Func) Inlinable() (bool, int) {
+	for _, d := range f.Diags {
+		if d.Kind == CanInline && d.Line == f.Line {
+			return true, d.Cost
+		}
+	}
+	return false, 0
+}
+
+// InlineFailure 返回函数本身不能被内联的诊断，能内联或没有 -m 诊断时 ok 为 false
+func (f *Func) InlineFailure() (d Diag, ok bool) {
+	for _, d := range f.Diags {
+		if d.Kind == CannotInline && d.Line == f.Line {
+			return d, true
+		}
+	}
+	return Diag{}, false
+}
+
+// InlinedCalls 返回函数体内被内联的调用
+func (f *Func) InlinedCalls() []Diag {
+	return f.filter(InlineCall)
+}
+
+// BoundsChecks 返回函数体内仍保留的边界检查，需要以 -d=ssa/check_bce/debug=1 编译
+func (f *Func) BoundsChecks() []Diag {
+	return f.filter(BoundsCheck)
+}
+
+func (f *Func) filter(kind Kind) []Diag {
+	var ds []Diag
+	for _, d := range f.Diags {
+		if d.Kind == kind {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// Package 是编译一个包得到的诊断
+type Package struct {
+	Dir string
+	// Funcs 按文件和行号排序
+	Funcs []*Func
+	// Diags 为全部诊断，包括不属于任何函数的（如自动生成的代码）
+	Diags []Diag
+}
+
+// Func 按名字查找函数，方法的名字为 T.M 或 (*T).M，不存在时返回 nil
+func (p *Package) Func(name string) *Func {
+	for _, f := range p.Funcs {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Compile 以 -gcflags=flags 编译 pkg 并解析诊断，flags 为空时使用 -m=2。
+// tests 为 true 时使用 go test -c 编译，以包含 _test.go 中的函数。
+// 编译结果不会写入磁盘，诊断会由构建缓存重放，因此重复调用的代价很小。
+func Compile(pkg string, tests bool, flags ...string) (*Package, error) {
+	if len(flags) == 0 {
+		flags = []string{"-m=2"}
+	}
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list %s: %v", pkg, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	args := []string{"build"}
+	if tests {
+		args = []string{"test", "-c"}
+	}
+	args = append(args, "-o", os.DevNull, "-gcflags="+strings.Join(flags, " "), ".")
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	diags, err := Parse(&stderr)
+	if err != nil {
+		return nil, err
+	}
+	for i := range diags {
+		if !filepath.IsAbs(diags[i].File) && !strings.HasPrefix(diags[i].File, "<") {
+			diags[i].File = filepath.Join(dir, diags[i].File)
+		}
+	}
+	p := &Package{Dir: dir, Diags: diags}
+	if err := p.attach(tests); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// attach 解析包内源文件，把诊断归属到所在的函数
+func (p *Package) attach(tests bool) error {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, p.Dir, func(fi os.FileInfo) bool {
+		return tests || !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return err
+	}
+	type span struct {
+		fn         *Func
+		start, end token.Position
+	}
+	spans := make(map[string][]span)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				start := fset.Position(fd.Pos())
+				name := funcName(fd)
+				fn := &Func{Name: name, File: start.Filename, Line: fset.Position(fd.Name.Pos()).Line}
+				p.Funcs = append(p.Funcs, fn)
+				spans[start.Filename] = append(spans[start.Filename], span{fn, start, fset.Position(fd.End())})
+			}
+		}
+	}
+	for i := range p.Diags {
+		d := &p.Diags[i]
+		for _, s := range spans[d.File] {
+			if before(s.start, d.Line, d.Col) && !before(s.end, d.Line, d.Col) {
+				d.Func = s.fn.Name
+				s.fn.Diags = append(s.fn.Diags, *d)
+				break
+			}
+		}
+	}
+	sort.Slice(p.Funcs, func(i, j int) bool {
+		a, b := p.Funcs[i], p.Funcs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return nil
+}
+
+// before 报告 pos 是否不晚于 line:col
+func before(pos token.Position, line, col int) bool {
+	return pos.Line < line || pos.Line == line && pos.Column <= col
+}
+
+// funcName 返回与编译器一致的函数名：f、T.M 或 (*T).M
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	typ := fd.Recv.List[0].Type
+	ptr := false
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ, ptr = star.X, true
+	}
+	var recv string
+	switch t := typ.(type) {
+	case *ast.Ident:
+		recv = t.Name
+	case *ast.IndexExpr:
+		recv = fmt.Sprint(t.X)
+	case *ast.IndexListExpr:
+		recv = fmt.Sprint(t.X)
+	}
+	if ptr {
+		return "(*" + recv + ")." + fd.Name.Name
+	}
+	return recv + "." + fd.Name.Name
+}
@@ -0,0 +1,152 @@
+// Package gcdiag 解析编译器诊断输出（-gcflags=-m=2 等），
+// 得到结构化的逃逸分析和内联记录，并按所在函数归类。
+// 配合 AssertNoEscape，可以把 struct_test.go 中"传值不逃逸、传指针逃逸"的结论变成 CI 中可检查的断言。
+package gcdiag
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind 是诊断的类别
+type Kind string
+
+const (
+	// Escapes 为 "X escapes to heap"，X 的内存分配在堆上
+	Escapes Kind = "escapes to heap"
+	// MovedToHeap 为 "moved to heap: X"，局部变量 X 被移动到堆上
+	MovedToHeap Kind = "moved to heap"
+	// NoEscape 为 "X does not escape"
+	NoEscape Kind = "does not escape"
+	// LeakParam 为 "leaking param: X"，参数 X 被堆上的对象引用，调用方传入的值会逃逸
+	LeakParam Kind = "leaking param"
+	// LeakParamContent 为 "leaking param content: X"，参数 X 指向的内容逃逸
+	LeakParamContent Kind = "leaking param content"
+	// LeakParamResult 为 "leaking param: X to result ~r0 level=0"，参数 X 仅流向返回值，本身不会导致逃逸
+	LeakParamResult Kind = "leaking param to result"
+	// CanInline 为 "can inline F"，Subject 为函数名
+	CanInline Kind = "can inline"
+	// CannotInline 为 "cannot inline F: reason"，Subject 为函数名，
+	// 因代价超出预算而失败时 Cost 和 Budget 给出具体数值
+	CannotInline Kind = "cannot inline"
+	// InlineCall 为 "inlining call to F"，Subject 为被内联的函数名
+	InlineCall Kind = "inlining call"
+	// BoundsCheck 为 -d=ssa/check_bce/debug=1 输出的 "Found IsInBounds" 或 "Found IsSliceInBounds"，
+	// 表示该位置的索引或切片操作仍保留了边界检查，Subject 为检查的类型
+	BoundsCheck Kind = "bounds check"
+	// Other 为其他无法识别的诊断
+	Other Kind = "other"
+)
+
+// Diag 是一条编译器诊断
+type Diag struct {
+	// File 为编译器输出的文件路径，Compile 会将其转换为绝对路径
+	File string
+	Line int
+	Col  int
+	Kind Kind
+	// Subject 为诊断的对象：变量名、表达式或函数名
+	Subject string
+	// Cost 为 -m=2 时 can inline 给出的内联代价，或 cannot inline 时超出预算的代价
+	Cost int
+	// Budget 为 cannot inline 时的内联预算
+	Budget int
+	// Reason 为 cannot inline 的原因
+	Reason string
+	// Func 为诊断所在的函数，由 Compile 根据源码位置填充，方法的格式为 T.M 或 (*T).M，
+	// 闭包归属于外层函数
+	Func    string
+	Message string
+}
+
+// HeapAlloc 报告该诊断是否意味着有值被分配到了堆上
+func (d *Diag) HeapAlloc() bool {
+	switch d.Kind {
+	case Escapes, MovedToHeap, LeakParam, LeakParamContent:
+		return true
+	}
+	return false
+}
+
+// Parse 解析编译器输出。-m=2 时的逃逸原因说明（以 ":" 结尾的标题行和缩进的 flow 行）会被跳过，
+// 只保留结论行；不带位置的行（如 "# pkg"）也会被跳过。
+func Parse(r io.Reader) ([]Diag, error) {
+	var diags []Diag
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if d, ok := parseLine(sc.Text()); ok {
+			diags = append(diags, d)
+		}
+	}
+	return diags, sc.Err()
+}
+
+var (
+	position = regexp.MustCompile(`^(.+?):(\d+):(\d+): (.*)$`)
+	budget   = regexp.MustCompile(`cost (\d+) exceeds budget (\d+)`)
+)
+
+// parseLine 解析形如 "./slices_test.go:8:16: leaking param: s" 的行
+func parseLine(line string) (Diag, bool) {
+	m := position.FindStringSubmatch(line)
+	if m == nil {
+		return Diag{}, false
+	}
+	msg := m[4]
+	if strings.HasPrefix(msg, " ") || strings.HasSuffix(msg, ":") {
+		return Diag{}, false
+	}
+	d := Diag{File: m[1], Message: msg}
+	d.Line, _ = strconv.Atoi(m[2])
+	d.Col, _ = strconv.Atoi(m[3])
+	classify(&d)
+	return d, true
+}
+
+func classify(d *Diag) {
+	msg := d.Message
+	switch {
+	case strings.HasPrefix(msg, "moved to heap: "):
+		d.Kind, d.Subject = MovedToHeap, strings.TrimPrefix(msg, "moved to heap: ")
+	case strings.HasSuffix(msg, " escapes to heap"):
+		d.Kind, d.Subject = Escapes, strings.TrimSuffix(msg, " escapes to heap")
+	case strings.HasSuffix(msg, " does not escape"):
+		d.Kind, d.Subject = NoEscape, strings.TrimSuffix(msg, " does not escape")
+	case strings.HasPrefix(msg, "leaking param content: "):
+		d.Kind, d.Subject = LeakParamContent, strings.TrimPrefix(msg, "leaking param content: ")
+	case strings.HasPrefix(msg, "leaking param: "):
+		d.Kind, d.Subject = LeakParam, strings.TrimPrefix(msg, "leaking param: ")
+		if i := strings.Index(d.Subject, " to result "); i >= 0 {
+			d.Kind, d.Subject = LeakParamResult, d.Subject[:i]
+		}
+	case strings.HasPrefix(msg, "can inline "):
+		d.Kind, d.Subject = CanInline, strings.TrimPrefix(msg, "can inline ")
+		if i := strings.Index(d.Subject, " with cost "); i >= 0 {
+			cost := d.Subject[i+len(" with cost "):]
+			if j := strings.IndexByte(cost, ' '); j >= 0 {
+				cost = cost[:j]
+			}
+			d.Cost, _ = strconv.Atoi(cost)
+			d.Subject = d.Subject[:i]
+		}
+	case strings.HasPrefix(msg, "cannot inline "):
+		d.Kind, d.Subject = CannotInline, strings.TrimPrefix(msg, "cannot inline ")
+		if i := strings.Index(d.Subject, ": "); i >= 0 {
+			d.Subject, d.Reason = d.Subject[:i], d.Subject[i+2:]
+		}
+		if m := budget.FindStringSubmatch(d.Reason); m != nil {
+			d.Cost, _ = strconv.Atoi(m[1])
+			d.Budget, _ = strconv.Atoi(m[2])
+		}
+	case strings.HasPrefix(msg, "Found Is") && strings.HasSuffix(msg, "InBounds"):
+		d.Kind, d.Subject = BoundsCheck, strings.TrimPrefix(msg, "Found ")
+	case strings.HasPrefix(msg, "inlining call to "):
+		d.Kind, d.Subject = InlineCall, strings.TrimPrefix(msg, "inlining call to ")
+	default:
+		d.Kind = Other
+	}
+}
@@ -0,0 +1,83 @@
+package gcdiag
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `# highPerformance/highprog [highPerformance/highprog.test]
+./slices_test.go:8:6: can inline TrimSpace with cost 24 as: func([]byte) []byte { b := s[:0]; for loop; return b }
+./slices_test.go:21:30: inlining call to TrimSpace
+./slices_test.go:3:19: s escapes to heap in byPtr:
+./slices_test.go:3:19:   flow: ~r0 ← &s:
+./slices_test.go:3:19:     from &s (address-of) at ./main.go:3:40
+./slices_test.go:3:19: moved to heap: s
+./slices_test.go:8:16: leaking param: s to result ~r0 level=0
+./slices_test.go:7:11: leaking param: p
+./slices_test.go:7:11: leaking param content: q
+./slices_test.go:13:14: append escapes to heap
+./slices_test.go:19:19: t does not escape
+./slices_test.go:19:6: cannot inline TestEmpSlice: function too complex: cost 111 exceeds budget 80
+./slices_test.go:25:6: cannot inline fib: recursive
+./range_test.go:16:24: Found IsSliceInBounds
+<autogenerated>:1: inlining call to reflect.flag.kind
+`
+
+func TestParse(t *testing.T) {
+	diags, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		kind    Kind
+		subject string
+	}{
+		{CanInline, "TrimSpace"},
+		{InlineCall, "TrimSpace"},
+		{MovedToHeap, "s"},
+		{LeakParamResult, "s"},
+		{LeakParam, "p"},
+		{LeakParamContent, "q"},
+		{Escapes, "append"},
+		{NoEscape, "t"},
+		{CannotInline, "TestEmpSlice"},
+		{CannotInline, "fib"},
+		{BoundsCheck, "IsSliceInBounds"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diags, want %d: %+v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		if diags[i].Kind != w.kind || diags[i].Subject != w.subject {
+			t.Errorf("diag %d: got %s %q, want %s %q", i, diags[i].Kind, diags[i].Subject, w.kind, w.subject)
+		}
+	}
+	if diags[0].Cost != 24 || diags[0].Line != 8 || diags[0].Col != 6 {
+		t.Errorf("unexpected position or cost: %+v", diags[0])
+	}
+	if d := diags[8]; d.Cost != 111 || d.Budget != 80 || d.Reason != "function too complex: cost 111 exceeds budget 80" {
+		t.Errorf("unexpected cannot inline: %+v", d)
+	}
+	if d := diags[9]; d.Budget != 0 || d.Reason != "recursive" {
+		t.Errorf("unexpected cannot inline: %+v", d)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	p, err := Compile("highPerformance/highprog", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Func("TrimSpace")
+	if f == nil {
+		t.Fatal("TrimSpace not found")
+	}
+	if ok, cost := f.Inlinable(); !ok || cost == 0 {
+		t.Errorf("TrimSpace should be inlinable, got %v %d", ok, cost)
+	}
+	for _, d := range f.Diags {
+		if d.Func != "TrimSpace" {
+			t.Errorf("diag attributed to %q: %+v", d.Func, d)
+		}
+	}
+}
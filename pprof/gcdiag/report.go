@@ -0,0 +1,62 @@
+package gcdiag
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// WriteEscapes 输出每个函数的逃逸分析结果，只列出有诊断的函数，
+// all 为 false 时省略 does not escape 和内联记录
+func (p *Package) WriteEscapes(w io.Writer, all bool) {
+	for _, f := range p.Funcs {
+		var ds []Diag
+		for _, d := range f.Diags {
+			if all || d.HeapAlloc() {
+				ds = append(ds, d)
+			}
+		}
+		if len(ds) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%s:%d)", f.Name, filepath.Base(f.File), f.Line)
+		if ok, cost := f.Inlinable(); ok {
+			fmt.Fprintf(w, " inlinable cost %d", cost)
+		}
+		fmt.Fprintln(w)
+		for _, d := range ds {
+			fmt.Fprintf(w, "\t%d:%d\t%s\n", d.Line, d.Col, d.Message)
+		}
+	}
+}
+
+// WriteOptimizations 输出每个函数的内联决策、内联的调用以及剩余的边界检查，
+// 需要以 -m 和 -d=ssa/check_bce/debug=1 编译才能得到完整的结果
+func (p *Package) WriteOptimizations(w io.Writer) {
+	for _, f := range p.Funcs {
+		fmt.Fprintf(w, "%s (%s:%d)", f.Name, filepath.Base(f.File), f.Line)
+		if ok, cost := f.Inlinable(); ok {
+			fmt.Fprint(w, " inlinable")
+			if cost > 0 {
+				fmt.Fprintf(w, " cost %d", cost)
+			}
+		} else if d, ok := f.InlineFailure(); ok {
+			if d.Budget > 0 {
+				fmt.Fprintf(w, " not inlinable: cost %d exceeds budget %d", d.Cost, d.Budget)
+			} else {
+				fmt.Fprintf(w, " not inlinable: %s", d.Reason)
+			}
+		}
+		fmt.Fprintln(w)
+		for _, d := range f.InlinedCalls() {
+			fmt.Fprintf(w, "\t%d:%d\tinlined %s\n", d.Line, d.Col, d.Subject)
+		}
+		bcs := f.BoundsChecks()
+		for _, d := range bcs {
+			fmt.Fprintf(w, "\t%d:%d\tbounds check %s\n", d.Line, d.Col, d.Subject)
+		}
+		if len(bcs) == 0 {
+			fmt.Fprintln(w, "\tno bounds checks")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package gotrace
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `SCHED 0ms: gomaxprocs=4 idleprocs=2 threads=5 spinningthreads=1 needspinning=0 idlethreads=1 runqueue=3 [ 1 0 2 0 ] schedticks=[ 0 0 0 0 ]
+gc 1 @0.027s 4%: 0.013+2.3+0.008 ms clock, 0.013+1.3/0.5/0.1+0.008 ms cpu, 3->4->1 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 4 P
+some test output
+SCHED 10ms: gomaxprocs=4 idleprocs=0 threads=6 spinningthreads=0 idlethreads=0 runqueue=1 [0 0 0 0]
+gc 2 @0.031s 7%: 0.017+2.1+0.5 ms clock, 0.017+1.2/0/0+0.003 ms cpu, 3->5->2 MB, 6 MB goal, 4 P (forced)
+`
+
+func TestParse(t *testing.T) {
+	tr, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.GC) != 2 || len(tr.Sched) != 2 {
+		t.Fatalf("got %d gc and %d sched events", len(tr.GC), len(tr.Sched))
+	}
+	gc := tr.GC[0]
+	if gc.Cycle != 1 || gc.At != 27*time.Millisecond || gc.CPUPercent != 4 || gc.Procs != 4 || gc.Forced {
+		t.Fatalf("unexpected gc event: %+v", gc)
+	}
+	if gc.MarkClock != 2300*time.Microsecond || gc.AssistCPU != 1300*time.Microsecond || gc.BackgroundCPU != 500*time.Microsecond {
+		t.Fatalf("unexpected gc times: %+v", gc)
+	}
+	if gc.HeapStart != 3<<20 || gc.HeapEnd != 4<<20 || gc.HeapLive != 1<<20 || gc.HeapGoal != 4<<20 {
+		t.Fatalf("unexpected gc heap: %+v", gc)
+	}
+	if !tr.GC[1].Forced || tr.GC[1].HeapGoal != 6<<20 {
+		t.Fatalf("unexpected gc event: %+v", tr.GC[1])
+	}
+	s := tr.Sched[0]
+	if s.GOMAXPROCS != 4 || s.IdleProcs != 2 || s.Threads != 5 || s.RunQueue != 3 || len(s.LocalRunQueues) != 4 || s.LocalRunQueues[2] != 2 {
+		t.Fatalf("unexpected sched event: %+v", s)
+	}
+	if len(tr.Sched[1].LocalRunQueues) != 4 {
+		t.Fatalf("unexpected sched event: %+v", tr.Sched[1])
+	}
+
+	sum := tr.GCSummary()
+	if sum.Cycles != 2 || sum.Forced != 1 || sum.PauseMax != 517*time.Microsecond || sum.MaxHeapLive != 2<<20 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+	tr.Summary(os.Stdout)
+}
+
+func TestRunTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a test binary")
+	}
+	var stdout bytes.Buffer
+	tr, err := RunTest(context.Background(), "highPerformance/benchmark", 10, &stdout, os.Stderr,
+		"-test.run=^$", "-test.bench=BenchmarkGenerate1000$", "-test.benchtime=100x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.GC) == 0 || len(tr.Sched) == 0 {
+		t.Fatalf("got %d gc and %d sched events", len(tr.GC), len(tr.Sched))
+	}
+	if !strings.Contains(stdout.String(), "BenchmarkGenerate1000") {
+		t.Fatalf("unexpected stdout: %s", stdout.String())
+	}
+}
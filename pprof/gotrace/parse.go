@@ -0,0 +1,201 @@
+// Package gotrace 解析 GODEBUG=gctrace=1,schedtrace=N 输出到 stderr 的跟踪行，
+// 得到结构化的 GC 事件和调度器事件，并给出汇总。
+// studentPool、bufferPool 这类优化的出发点是"降低 GC 压力"，
+// 有了 GC 次数、暂停时间和 GC 占用的 CPU 比例，这个说法就可以被度量。
+package gotrace
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GCEvent 对应一行 gctrace 输出，格式说明见 runtime 包文档中的 GODEBUG 一节：
+// gc 1 @0.027s 4%: 0.013+2.3+0.008 ms clock, 0.013+1.3/0/0+0.008 ms cpu, 3->4->1 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 1 P
+type GCEvent struct {
+	Cycle int
+	// At 为相对程序启动的时间
+	At time.Duration
+	// CPUPercent 为程序启动以来 GC 占用 CPU 时间的百分比
+	CPUPercent int
+
+	// 墙上时间：STW 清扫终止、并发标记扫描、STW 标记终止
+	SweepTermClock time.Duration
+	MarkClock      time.Duration
+	MarkTermClock  time.Duration
+
+	// CPU 时间：STW 清扫终止、辅助标记、后台标记、空闲标记、STW 标记终止
+	SweepTermCPU  time.Duration
+	AssistCPU     time.Duration
+	BackgroundCPU time.Duration
+	IdleCPU       time.Duration
+	MarkTermCPU   time.Duration
+
+	// 堆大小，单位为字节，精度为 MB：GC 开始时、GC 结束时、存活堆，以及目标堆大小
+	HeapStart int64
+	HeapEnd   int64
+	HeapLive  int64
+	HeapGoal  int64
+	// Stacks 和 Globals 在 Go 1.18 之前的版本中没有输出，此时为 0
+	Stacks  int64
+	Globals int64
+
+	Procs  int
+	Forced bool
+}
+
+// Pause 返回本次 GC 的 STW 总时长
+func (e *GCEvent) Pause() time.Duration {
+	return e.SweepTermClock + e.MarkTermClock
+}
+
+// SchedEvent 对应一行 schedtrace 输出：
+// SCHED 13ms: gomaxprocs=1 idleprocs=0 threads=3 spinningthreads=1 needspinning=0 idlethreads=0 runqueue=0 [ 0 ] schedticks=[ 6 ]
+type SchedEvent struct {
+	At              time.Duration
+	GOMAXPROCS      int
+	IdleProcs       int
+	Threads         int
+	SpinningThreads int
+	IdleThreads     int
+	// RunQueue 为全局运行队列长度，LocalRunQueues 为每个 P 本地运行队列的长度
+	RunQueue       int
+	LocalRunQueues []int
+	// Fields 保存所有 key=value 形式的整数字段，包括上面未单独列出的字段
+	Fields map[string]int
+}
+
+// Trace 是一次运行的全部跟踪事件
+type Trace struct {
+	GC    []GCEvent
+	Sched []SchedEvent
+}
+
+var gcLine = regexp.MustCompile(`^gc (\d+) @([0-9.]+)s (\d+)%: ` +
+	`([0-9.]+)\+([0-9.]+)\+([0-9.]+) ms clock, ` +
+	`([0-9.]+)\+([0-9.]+)/([0-9.]+)/([0-9.]+)\+([0-9.]+) ms cpu, ` +
+	`(\d+)->(\d+)->(\d+) MB, (\d+) MB goal, ` +
+	`(?:(\d+) MB stacks, )?(?:(\d+) MB globals, )?(\d+) P( \(forced\))?`)
+
+// Parse 从 r 中读取跟踪行，其余的输出会被忽略。
+// r 中只能包含一个进程的输出：go test 和 go run 自身也会响应 GODEBUG，
+// 因此应当先编译出二进制再设置 GODEBUG 运行，参见 Run。
+func Parse(r io.Reader) (*Trace, error) {
+	t := &Trace{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "gc "):
+			if e, ok := ParseGC(line); ok {
+				t.GC = append(t.GC, e)
+			}
+		case strings.HasPrefix(line, "SCHED "):
+			if e, ok := ParseSched(line); ok {
+				t.Sched = append(t.Sched, e)
+			}
+		}
+	}
+	return t, sc.Err()
+}
+
+// ParseGC 解析一行 gctrace 输出
+func ParseGC(line string) (GCEvent, bool) {
+	m := gcLine.FindStringSubmatch(line)
+	if m == nil {
+		return GCEvent{}, false
+	}
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	mb := func(s string) int64 { return int64(atoi(s)) << 20 }
+	ms := func(s string) time.Duration {
+		f, _ := strconv.ParseFloat(s, 64)
+		return time.Duration(f * float64(time.Millisecond))
+	}
+	at, _ := strconv.ParseFloat(m[2], 64)
+	return GCEvent{
+		Cycle:          atoi(m[1]),
+		At:             time.Duration(at * float64(time.Second)),
+		CPUPercent:     atoi(m[3]),
+		SweepTermClock: ms(m[4]),
+		MarkClock:      ms(m[5]),
+		MarkTermClock:  ms(m[6]),
+		SweepTermCPU:   ms(m[7]),
+		AssistCPU:      ms(m[8]),
+		BackgroundCPU:  ms(m[9]),
+		IdleCPU:        ms(m[10]),
+		MarkTermCPU:    ms(m[11]),
+		HeapStart:      mb(m[12]),
+		HeapEnd:        mb(m[13]),
+		HeapLive:       mb(m[14]),
+		HeapGoal:       mb(m[15]),
+		Stacks:         mb(m[16]),
+		Globals:        mb(m[17]),
+		Procs:          atoi(m[18]),
+		Forced:         m[19] != "",
+	}, true
+}
+
+// ParseSched 解析一行 schedtrace 输出，scheddetail=1 时额外输出的 P/M/G 明细行不在此处理
+func ParseSched(line string) (SchedEvent, bool) {
+	i := strings.Index(line, "ms: ")
+	if !strings.HasPrefix(line, "SCHED ") || i < 0 {
+		return SchedEvent{}, false
+	}
+	at, err := strconv.Atoi(line[len("SCHED "):i])
+	if err != nil {
+		return SchedEvent{}, false
+	}
+	e := SchedEvent{At: time.Duration(at) * time.Millisecond, Fields: make(map[string]int)}
+	fields := strings.Fields(line[i+len("ms: "):])
+	for k := 0; k < len(fields); k++ {
+		f := fields[k]
+		key, val := f, ""
+		if j := strings.IndexByte(f, '='); j >= 0 {
+			key, val = f[:j], f[j+1:]
+		}
+		if val == "" || strings.HasPrefix(val, "[") {
+			// 列表有 "[0 0]" 和 "[ 0 0 ]" 两种写法，runqueue 后面的列表没有 key
+			var list []int
+			list, k = parseList(fields, k, strings.TrimPrefix(f, key+"="))
+			if key == "[" || strings.HasPrefix(key, "[") {
+				e.LocalRunQueues = list
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(val); err == nil {
+			e.Fields[key] = n
+		}
+	}
+	e.GOMAXPROCS = e.Fields["gomaxprocs"]
+	e.IdleProcs = e.Fields["idleprocs"]
+	e.Threads = e.Fields["threads"]
+	e.SpinningThreads = e.Fields["spinningthreads"]
+	e.IdleThreads = e.Fields["idlethreads"]
+	e.RunQueue = e.Fields["runqueue"]
+	return e, true
+}
+
+// parseList 从 fields[k] 开始读取一个方括号列表，first 为 fields[k] 去掉 key= 后的部分，
+// 返回列表和列表最后一个元素的下标
+func parseList(fields []string, k int, first string) ([]int, int) {
+	var list []int
+	tok := first
+	for {
+		closed := strings.HasSuffix(tok, "]")
+		tok = strings.Trim(tok, "[]")
+		if n, err := strconv.Atoi(tok); err == nil {
+			list = append(list, n)
+		}
+		if closed || k+1 >= len(fields) {
+			return list, k
+		}
+		k++
+		tok = fields[k]
+	}
+}
@@ -0,0 +1,92 @@
+package gotrace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Run 设置 GODEBUG=gctrace=1 运行命令，schedtrace 大于 0 时同时开启 schedtrace=schedtrace（单位毫秒）。
+// 命令的 stdout 写入 stdout，stderr 中的跟踪行被解析，其余行写入 stderr。
+// 环境变量中已有的 GODEBUG 设置会被保留。
+func Run(ctx context.Context, schedtrace int, stdout, stderr io.Writer, name string, args ...string) (*Trace, error) {
+	godebug := "gctrace=1"
+	if schedtrace > 0 {
+		godebug += fmt.Sprintf(",schedtrace=%d", schedtrace)
+	}
+	if old := os.Getenv("GODEBUG"); old != "" {
+		godebug = old + "," + godebug
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "GODEBUG="+godebug)
+	cmd.Stdout = stdout
+	pr, pw := io.Pipe()
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		t   *Trace
+		err error
+	}
+	parsed := make(chan result, 1)
+	go func() {
+		var buf bytes.Buffer
+		t, err := Parse(io.TeeReader(pr, filter{&buf, stderr}))
+		// 读取出错时也要把管道读空，否则命令会因为写 stderr 阻塞
+		io.Copy(io.Discard, pr)
+		parsed <- result{t, err}
+	}()
+	err := cmd.Wait()
+	pw.Close()
+	r := <-parsed
+	if err != nil {
+		return r.t, err
+	}
+	return r.t, r.err
+}
+
+// RunTest 先用 go test -c 编译 pkg 的测试二进制，再设置 GODEBUG 运行它，
+// 这样跟踪行只来自测试进程，而不会混入 go 命令和编译器自身的 GC 输出。
+// args 为传给测试二进制的参数，如 -test.run=^$ -test.bench=Pool
+func RunTest(ctx context.Context, pkg string, schedtrace int, stdout, stderr io.Writer, args ...string) (*Trace, error) {
+	dir, err := os.MkdirTemp("", "gotrace")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "test.bin")
+	build := exec.CommandContext(ctx, "go", "test", "-c", "-o", bin, pkg)
+	build.Stdout, build.Stderr = stderr, stderr
+	if err := build.Run(); err != nil {
+		return nil, fmt.Errorf("build %s: %v", pkg, err)
+	}
+	return Run(ctx, schedtrace, stdout, stderr, bin, args...)
+}
+
+// filter 将非跟踪行写入 w
+type filter struct {
+	line *bytes.Buffer
+	w    io.Writer
+}
+
+func (f filter) Write(p []byte) (int, error) {
+	f.line.Write(p)
+	for {
+		b := f.line.Bytes()
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(b[:i+1])
+		f.line.Next(i + 1)
+		if f.w != nil && !strings.HasPrefix(line, "gc ") && !strings.HasPrefix(line, "SCHED ") {
+			f.w.Write([]byte(line))
+		}
+	}
+}
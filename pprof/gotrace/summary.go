@@ -0,0 +1,136 @@
+package gotrace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// GCSummary 是所有 GC 事件的汇总
+type GCSummary struct {
+	Cycles int
+	Forced int
+	// Span 为第一次到最后一次 GC 的时间跨度
+	Span time.Duration
+	// CPUPercent 为最后一次 GC 时报告的 GC CPU 占比
+	CPUPercent int
+	TotalPause time.Duration
+	PauseP50   time.Duration
+	PauseP99   time.Duration
+	PauseMax   time.Duration
+	// AssistCPU 为用户协程被迫辅助标记的 CPU 时间总和，分配越快该值越高
+	AssistCPU   time.Duration
+	MaxHeapGoal int64
+	MaxHeapLive int64
+	AvgHeapLive int64
+}
+
+// SchedSummary 是所有调度器事件的汇总
+type SchedSummary struct {
+	Samples        int
+	GOMAXPROCS     int
+	AvgIdleProcs   float64
+	MaxThreads     int
+	AvgRunQueue    float64
+	MaxRunQueue    int
+	AvgLocalQueued float64
+	MaxLocalQueued int
+}
+
+// GCSummary 汇总 GC 事件
+func (t *Trace) GCSummary() GCSummary {
+	var s GCSummary
+	if len(t.GC) == 0 {
+		return s
+	}
+	pauses := make([]time.Duration, 0, len(t.GC))
+	var live int64
+	for i := range t.GC {
+		e := &t.GC[i]
+		s.Cycles++
+		if e.Forced {
+			s.Forced++
+		}
+		pauses = append(pauses, e.Pause())
+		s.TotalPause += e.Pause()
+		s.AssistCPU += e.AssistCPU
+		live += e.HeapLive
+		if e.HeapGoal > s.MaxHeapGoal {
+			s.MaxHeapGoal = e.HeapGoal
+		}
+		if e.HeapLive > s.MaxHeapLive {
+			s.MaxHeapLive = e.HeapLive
+		}
+	}
+	last := t.GC[len(t.GC)-1]
+	s.Span = last.At - t.GC[0].At
+	s.CPUPercent = last.CPUPercent
+	s.AvgHeapLive = live / int64(len(t.GC))
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i] < pauses[j] })
+	s.PauseP50 = percentile(pauses, 0.5)
+	s.PauseP99 = percentile(pauses, 0.99)
+	s.PauseMax = pauses[len(pauses)-1]
+	return s
+}
+
+// SchedSummary 汇总调度器事件
+func (t *Trace) SchedSummary() SchedSummary {
+	var s SchedSummary
+	var idle, rq, local float64
+	for _, e := range t.Sched {
+		s.Samples++
+		s.GOMAXPROCS = e.GOMAXPROCS
+		idle += float64(e.IdleProcs)
+		rq += float64(e.RunQueue)
+		if e.RunQueue > s.MaxRunQueue {
+			s.MaxRunQueue = e.RunQueue
+		}
+		if e.Threads > s.MaxThreads {
+			s.MaxThreads = e.Threads
+		}
+		queued := 0
+		for _, n := range e.LocalRunQueues {
+			queued += n
+		}
+		local += float64(queued)
+		if queued > s.MaxLocalQueued {
+			s.MaxLocalQueued = queued
+		}
+	}
+	if s.Samples > 0 {
+		n := float64(s.Samples)
+		s.AvgIdleProcs, s.AvgRunQueue, s.AvgLocalQueued = idle/n, rq/n, local/n
+	}
+	return s
+}
+
+// Summary 输出 GC 和调度器的汇总
+func (t *Trace) Summary(w io.Writer) {
+	gc := t.GCSummary()
+	fmt.Fprintf(w, "gc: %d cycles (%d forced) over %v, %d%% cpu, assist %v\n",
+		gc.Cycles, gc.Forced, gc.Span.Round(time.Millisecond), gc.CPUPercent, gc.AssistCPU)
+	fmt.Fprintf(w, "gc pause: total %v p50 %v p99 %v max %v\n",
+		gc.TotalPause, gc.PauseP50, gc.PauseP99, gc.PauseMax)
+	fmt.Fprintf(w, "gc heap: live avg %d MB max %d MB, goal max %d MB\n",
+		gc.AvgHeapLive>>20, gc.MaxHeapLive>>20, gc.MaxHeapGoal>>20)
+	sched := t.SchedSummary()
+	if sched.Samples == 0 {
+		return
+	}
+	fmt.Fprintf(w, "sched: %d samples, gomaxprocs %d, idle P avg %.2f, threads max %d\n",
+		sched.Samples, sched.GOMAXPROCS, sched.AvgIdleProcs, sched.MaxThreads)
+	fmt.Fprintf(w, "sched runqueue: global avg %.2f max %d, local avg %.2f max %d\n",
+		sched.AvgRunQueue, sched.MaxRunQueue, sched.AvgLocalQueued, sched.MaxLocalQueued)
+}
+
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -0,0 +1,292 @@
+// Package memsnap 在两个时间点记录堆 profile 和 runtime/metrics，
+// 并报告哪些分配点的存活字节数/对象数增长了。
+// 相比 printMem 只打印一个 MemStats.Alloc，它能够回答"是谁留住了这些内存"，例如：
+// generateWithCap retains 100.00 MB (100 objects) <- testLastChars <- TestLastCharsBySlice
+package memsnap
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 需要对比的 runtime/metrics 指标，当前 Go 版本不支持的指标会被忽略
+var metricNames = []string{
+	"/gc/heap/live:bytes",
+	"/gc/heap/goal:bytes",
+	"/gc/heap/objects:objects",
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/total:bytes",
+	"/gc/cycles/total:gc-cycles",
+}
+
+// Frame 是分配点调用栈中的一帧
+type Frame struct {
+	Func string
+	File string
+	Line int
+}
+
+// Site 是一个分配点（即一条完整的分配调用栈）的存活内存
+type Site struct {
+	Stack        []Frame
+	InUseBytes   int64
+	InUseObjects int64
+}
+
+// Name 返回栈顶第一个非 runtime 的函数名（去掉包路径）
+func (s *Site) Name() string {
+	if i := s.topIndex(); i >= 0 {
+		return shortFunc(s.Stack[i].Func)
+	}
+	return "unknown"
+}
+
+func (s *Site) topIndex() int {
+	for i, f := range s.Stack {
+		if !strings.HasPrefix(f.Func, "runtime.") {
+			return i
+		}
+	}
+	return -1
+}
+
+// Snapshot 是某一时刻的堆 profile 与 runtime/metrics
+type Snapshot struct {
+	Time    time.Time
+	Sites   map[string]*Site
+	Metrics map[string]float64
+}
+
+// Take 强制执行一次 GC 后记录快照。
+// 堆 profile 只在 GC 完成时更新，所以这里需要两次 GC，才能让最近的分配出现在 profile 中。
+// 堆 profile 是按 runtime.MemProfileRate 采样的，小对象的分配点可能不会被记录，
+// 需要精确结果时可以在程序启动时将 runtime.MemProfileRate 设为 1。
+func Take() *Snapshot {
+	runtime.GC()
+	runtime.GC()
+	s := &Snapshot{
+		Time:    time.Now(),
+		Sites:   make(map[string]*Site),
+		Metrics: readMetrics(),
+	}
+	var records []runtime.MemProfileRecord
+	n, _ := runtime.MemProfile(nil, false)
+	for {
+		records = make([]runtime.MemProfileRecord, n+50)
+		var ok bool
+		n, ok = runtime.MemProfile(records, false)
+		if ok {
+			records = records[:n]
+			break
+		}
+	}
+	rate := int64(runtime.MemProfileRate)
+	for i := range records {
+		r := &records[i]
+		bytes, objects := scale(r.InUseBytes(), r.InUseObjects(), rate)
+		if objects == 0 {
+			continue
+		}
+		k, stack := resolve(r.Stack())
+		site, ok := s.Sites[k]
+		if !ok {
+			site = &Site{Stack: stack}
+			s.Sites[k] = site
+		}
+		site.InUseBytes += bytes
+		site.InUseObjects += objects
+	}
+	return s
+}
+
+// scale 将采样值还原为估计的真实值，算法与 runtime/pprof 的 scaleHeapSample 相同
+func scale(bytes, objects, rate int64) (int64, int64) {
+	if objects == 0 || bytes == 0 {
+		return 0, 0
+	}
+	if rate <= 1 {
+		return bytes, objects
+	}
+	avg := float64(bytes) / float64(objects)
+	s := 1 / (1 - math.Exp(-avg/float64(rate)))
+	return int64(float64(bytes) * s), int64(float64(objects) * s)
+}
+
+func resolve(pcs []uintptr) (string, []Frame) {
+	var (
+		b     strings.Builder
+		stack []Frame
+	)
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if f.Function != "" {
+			stack = append(stack, Frame{Func: f.Function, File: f.File, Line: f.Line})
+			fmt.Fprintf(&b, "%s:%d|", f.Function, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String(), stack
+}
+
+func readMetrics() map[string]float64 {
+	supported := make(map[string]bool)
+	for _, d := range metrics.All() {
+		supported[d.Name] = true
+	}
+	var samples []metrics.Sample
+	for _, name := range metricNames {
+		if supported[name] {
+			samples = append(samples, metrics.Sample{Name: name})
+		}
+	}
+	metrics.Read(samples)
+	m := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			m[s.Name] = float64(s.Value.Uint64())
+		case metrics.KindFloat64:
+			m[s.Name] = s.Value.Float64()
+		}
+	}
+	return m
+}
+
+// Growth 是一个分配点在两次快照之间的变化
+type Growth struct {
+	Site
+	// DeltaBytes 和 DeltaObjects 为 after - before，可能为负数
+	DeltaBytes   int64
+	DeltaObjects int64
+}
+
+// String 返回形如 "generateWithCap retains 100.00 MB (100 objects) <- testLastChars" 的描述，
+// 箭头后为调用链，最多展示 3 层
+func (g *Growth) String() string {
+	verb := "retains"
+	if g.DeltaBytes < 0 {
+		verb = "released"
+	}
+	s := fmt.Sprintf("%s %s %s (%d objects)", g.Name(), verb, formatBytes(abs(g.DeltaBytes)), abs(g.DeltaObjects))
+	i := g.topIndex()
+	if i < 0 {
+		return s
+	}
+	for n, f := range g.Stack[i+1:] {
+		if n == 3 || strings.HasPrefix(f.Func, "runtime.") || strings.HasPrefix(f.Func, "testing.") {
+			break
+		}
+		s += " <- " + shortFunc(f.Func)
+	}
+	top := g.Stack[i]
+	return s + fmt.Sprintf(" [%s:%d]", filepath.Base(top.File), top.Line)
+}
+
+// Report 是两次快照的对比结果
+type Report struct {
+	Elapsed time.Duration
+	// Sites 按存活字节数的增量从大到小排序
+	Sites []*Growth
+	// Metrics 为 runtime/metrics 指标的增量
+	Metrics map[string]float64
+}
+
+// Compare 对比两次快照
+func Compare(before, after *Snapshot) *Report {
+	r := &Report{
+		Elapsed: after.Time.Sub(before.Time),
+		Metrics: make(map[string]float64),
+	}
+	for k, s := range after.Sites {
+		g := &Growth{Site: *s, DeltaBytes: s.InUseBytes, DeltaObjects: s.InUseObjects}
+		if b, ok := before.Sites[k]; ok {
+			g.DeltaBytes -= b.InUseBytes
+			g.DeltaObjects -= b.InUseObjects
+		}
+		if g.DeltaBytes != 0 {
+			r.Sites = append(r.Sites, g)
+		}
+	}
+	for k, s := range before.Sites {
+		if _, ok := after.Sites[k]; !ok {
+			r.Sites = append(r.Sites, &Growth{Site: *s, DeltaBytes: -s.InUseBytes, DeltaObjects: -s.InUseObjects})
+		}
+	}
+	sort.Slice(r.Sites, func(i, j int) bool {
+		return r.Sites[i].DeltaBytes > r.Sites[j].DeltaBytes
+	})
+	for k, v := range after.Metrics {
+		r.Metrics[k] = v - before.Metrics[k]
+	}
+	return r
+}
+
+// Top 返回存活内存增长最多的 n 个分配点
+func (r *Report) Top(n int) []*Growth {
+	var top []*Growth
+	for _, g := range r.Sites {
+		if g.DeltaBytes <= 0 || len(top) == n {
+			break
+		}
+		top = append(top, g)
+	}
+	return top
+}
+
+// WriteTop 输出增长最多的 n 个分配点以及指标变化
+func (r *Report) WriteTop(w io.Writer, n int) {
+	fmt.Fprintf(w, "heap growth over %v:\n", r.Elapsed.Round(time.Millisecond))
+	for _, g := range r.Top(n) {
+		fmt.Fprintf(w, "  %s\n", g)
+	}
+	names := make([]string, 0, len(r.Metrics))
+	for k := range r.Metrics {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v := r.Metrics[k]
+		if strings.HasSuffix(k, ":bytes") {
+			fmt.Fprintf(w, "  %s %+.2f MB\n", k, v/1024/1024)
+		} else {
+			fmt.Fprintf(w, "  %s %+.0f\n", k, v)
+		}
+	}
+}
+
+func shortFunc(fn string) string {
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	if i := strings.IndexByte(fn, '.'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return fn
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(n)/1024/1024)
+	case n >= 1<<10:
+		return fmt.Sprintf("%.2f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package memsnap
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var retained [][]byte
+
+func retain(n int) {
+	for i := 0; i < n; i++ {
+		retained = append(retained, make([]byte, 1<<20))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// 默认每 512KB 采样一次，1MB 的分配按采样估算的总量有一半的概率低于 20MB，测试时记录每一次分配
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = 1
+	before := Take()
+	retain(20)
+	r := Compare(before, Take())
+	r.WriteTop(os.Stdout, 3)
+	top := r.Top(1)
+	if len(top) == 0 {
+		t.Fatal("no growth reported")
+	}
+	if top[0].Name() != "retain" {
+		t.Fatalf("got %q, want retain", top[0].Name())
+	}
+	if top[0].DeltaBytes < 20<<20 {
+		t.Fatalf("got %d bytes, want at least %d", top[0].DeltaBytes, 20<<20)
+	}
+	if !strings.Contains(top[0].String(), "<- TestCompare") {
+		t.Fatalf("missing caller in %q", top[0])
+	}
+	retained = nil
+	runtime.GC()
+}
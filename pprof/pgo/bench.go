@@ -0,0 +1,161 @@
+package pgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Result 是一行基准测试输出，如：
+// BenchmarkFib-8   	     100	  2863410 ns/op	       0 B/op	       0 allocs/op
+type Result struct {
+	// Name 去掉了 Benchmark 前缀和 -GOMAXPROCS 后缀
+	Name       string
+	Iterations int
+	// Values 以单位为键，如 ns/op、B/op、allocs/op 以及 b.ReportMetric 报告的自定义单位
+	Values map[string]float64
+}
+
+// ParseBench 解析 go test -bench 的输出，非基准结果行会被忽略
+func ParseBench(r io.Reader) ([]Result, error) {
+	var rs []Result
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		r := Result{Name: trimName(fields[0]), Iterations: n, Values: make(map[string]float64)}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			r.Values[fields[i+1]] = v
+		}
+		rs = append(rs, r)
+	}
+	return rs, sc.Err()
+}
+
+func trimName(name string) string {
+	name = strings.TrimPrefix(name, "Benchmark")
+	if i := strings.LastIndexByte(name, '-'); i >= 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			name = name[:i]
+		}
+	}
+	return name
+}
+
+// Stat 是同一个基准多次运行的统计
+type Stat struct {
+	N    int
+	Mean float64
+	// Spread 为最大偏差占均值的比例，与 benchstat 的 ± 含义相同
+	Spread float64
+}
+
+// Delta 是一个基准在某个单位上前后两组结果的对比
+type Delta struct {
+	Name   string
+	Unit   string
+	Before Stat
+	After  Stat
+	// Change 为 (after - before) / before，负数表示变少（对 ns/op 来说即变快）
+	Change float64
+}
+
+// Compare 按基准名和单位对比两组结果，只包含两组都有的项
+func Compare(before, after []Result) []Delta {
+	b, a := group(before), group(after)
+	var ds []Delta
+	for key, bv := range b {
+		av, ok := a[key]
+		if !ok {
+			continue
+		}
+		d := Delta{Name: key[0], Unit: key[1], Before: stat(bv), After: stat(av)}
+		if d.Before.Mean != 0 {
+			d.Change = (d.After.Mean - d.Before.Mean) / d.Before.Mean
+		}
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool {
+		if ds[i].Name != ds[j].Name {
+			return ds[i].Name < ds[j].Name
+		}
+		return unitOrder(ds[i].Unit) < unitOrder(ds[j].Unit)
+	})
+	return ds
+}
+
+// WriteReport 以表格形式输出对比结果
+func WriteReport(w io.Writer, ds []Delta) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "name\tunit\tpgo=off\tpgo=on\tdelta")
+	for _, d := range ds {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%+.2f%%\n", d.Name, d.Unit, format(d.Before), format(d.After), d.Change*100)
+	}
+	return tw.Flush()
+}
+
+func format(s Stat) string {
+	v := strconv.FormatFloat(s.Mean, 'f', 0, 64)
+	if s.Mean < 1000 {
+		v = strconv.FormatFloat(s.Mean, 'g', 4, 64)
+	}
+	if s.N > 1 {
+		v += fmt.Sprintf(" ± %.0f%%", s.Spread*100)
+	}
+	return v
+}
+
+func group(rs []Result) map[[2]string][]float64 {
+	m := make(map[[2]string][]float64)
+	for _, r := range rs {
+		for unit, v := range r.Values {
+			k := [2]string{r.Name, unit}
+			m[k] = append(m[k], v)
+		}
+	}
+	return m
+}
+
+func stat(vs []float64) Stat {
+	s := Stat{N: len(vs)}
+	for _, v := range vs {
+		s.Mean += v
+	}
+	s.Mean /= float64(len(vs))
+	if s.Mean == 0 {
+		return s
+	}
+	for _, v := range vs {
+		if d := math.Abs(v-s.Mean) / s.Mean; d > s.Spread {
+			s.Spread = d
+		}
+	}
+	return s
+}
+
+func unitOrder(unit string) string {
+	switch unit {
+	case "ns/op":
+		return "0"
+	case "B/op":
+		return "1"
+	case "allocs/op":
+		return "2"
+	}
+	return "3" + unit
+}
@@ -0,0 +1,105 @@
+// Package pgo 把收集到的 CPU profile 合并为 default.pgo，
+// 然后分别以 -pgo=off 和 -pgo=default.pgo 运行基准测试并给出对比报告，
+// 用来回答"PGO 对 fib、bubbleSort、json 反序列化这类负载到底有没有帮助"。
+package pgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Merge 使用 go tool pprof 将多个 CPU profile 合并为一个，写入 out。
+// 合并后的 profile 可以直接作为 -pgo 的输入，放在 main 包目录下命名为 default.pgo 时会被 -pgo=auto 自动使用。
+func Merge(ctx context.Context, out string, profiles ...string) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("no profiles to merge")
+	}
+	args := append([]string{"tool", "pprof", "-proto"}, profiles...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go tool pprof: %v\n%s", err, stderr.String())
+	}
+	if stdout.Len() == 0 {
+		return fmt.Errorf("go tool pprof produced an empty profile\n%s", stderr.String())
+	}
+	return os.WriteFile(out, stdout.Bytes(), 0644)
+}
+
+// Collect 运行 pkg 中匹配 bench 的基准测试并收集 CPU profile 写入 out，
+// 作为代表性负载的 profile 供 Merge 使用。
+// 测试二进制先由 go test -c 编译到临时目录，避免 -cpuprofile 在当前目录留下 pkg.test。
+func Collect(ctx context.Context, pkg, bench, out string, log io.Writer) error {
+	dir, err := os.MkdirTemp("", "pgo")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "test.bin")
+	if err := run(ctx, "", log, nil, "go", "test", "-c", "-pgo=off", "-o", bin, pkg); err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(out)
+	if err != nil {
+		return err
+	}
+	pkgDir, err := packageDir(ctx, pkg)
+	if err != nil {
+		return err
+	}
+	return run(ctx, pkgDir, log, log, bin, "-test.run=^$", "-test.bench="+bench, "-test.cpuprofile="+abs)
+}
+
+// Bench 以指定的 pgo 参数运行基准测试，pgo 为 "off" 时关闭 PGO，否则为 profile 的路径。
+// count 为每个基准的运行次数，用于计算均值和波动。
+func Bench(ctx context.Context, pkg, bench, pgo string, count int, log io.Writer) ([]Result, error) {
+	if pgo != "off" {
+		abs, err := filepath.Abs(pgo)
+		if err != nil {
+			return nil, err
+		}
+		pgo = abs
+	}
+	var out bytes.Buffer
+	w := io.Writer(&out)
+	if log != nil {
+		w = io.MultiWriter(&out, log)
+	}
+	err := run(ctx, "", w, log, "go", "test", "-run=^$", "-bench="+bench, "-benchmem",
+		"-count="+strconv.Itoa(count), "-pgo="+pgo, pkg)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBench(&out)
+}
+
+func packageDir(ctx context.Context, pkg string) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "list", "-f", "{{.Dir}}", pkg).Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s: %v", pkg, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func run(ctx context.Context, dir string, stdout, stderr io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var errBuf bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &errBuf
+	if stderr != nil {
+		cmd.Stderr = io.MultiWriter(&errBuf, stderr)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %v\n%s", name, strings.Join(args, " "), err, errBuf.String())
+	}
+	return nil
+}
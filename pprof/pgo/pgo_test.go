@@ -0,0 +1,95 @@
+package pgo
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+const before = `goos: linux
+goarch: amd64
+pkg: highPerformance/benchmark
+BenchmarkFib-8      	     100	  3000000 ns/op	       0 B/op	       0 allocs/op
+BenchmarkFib-8      	     100	  3200000 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBubble     	       5	 90000000 ns/op
+PASS
+`
+
+const after = `BenchmarkFib-8      	     100	  2800000 ns/op	       0 B/op	       0 allocs/op
+BenchmarkFib-8      	     100	  2780000 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBubble     	       5	 99000000 ns/op
+`
+
+func TestCompare(t *testing.T) {
+	b, err := ParseBench(strings.NewReader(before))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 3 || b[0].Name != "Fib" || b[2].Name != "Bubble" || b[0].Values["ns/op"] != 3000000 {
+		t.Fatalf("unexpected results: %+v", b)
+	}
+	a, _ := ParseBench(strings.NewReader(after))
+	ds := Compare(b, a)
+	if len(ds) != 4 {
+		t.Fatalf("got %d deltas, want 4: %+v", len(ds), ds)
+	}
+	bubble, fib := ds[0], ds[1]
+	if bubble.Name != "Bubble" || bubble.Change < 0.099 || bubble.Change > 0.101 {
+		t.Fatalf("unexpected delta: %+v", bubble)
+	}
+	if fib.Name != "Fib" || fib.Unit != "ns/op" || fib.Before.Mean != 3100000 || fib.Before.N != 2 {
+		t.Fatalf("unexpected delta: %+v", fib)
+	}
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, ds); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + buf.String())
+}
+
+func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}
+
+// writeProfile 收集一段 CPU 密集负载的 profile。
+// 不直接使用 ../cpu.pprof，因为 TestPprof 会在并行运行的另一个测试进程中重写它
+func writeProfile(t *testing.T, path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := pprof.StartCPUProfile(f); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); time.Since(start) < 50*time.Millisecond; {
+		fib(20)
+	}
+	pprof.StopCPUProfile()
+}
+
+func TestMerge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go tool pprof")
+	}
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.pprof"), filepath.Join(dir, "b.pprof")
+	writeProfile(t, a)
+	writeProfile(t, b)
+	out := filepath.Join(dir, "default.pgo")
+	if err := Merge(context.Background(), out, a, b); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(out)
+	if err != nil || fi.Size() == 0 {
+		t.Fatalf("merged profile missing: %v", err)
+	}
+}
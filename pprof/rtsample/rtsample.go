@@ -0,0 +1,207 @@
+// Package rtsample 在实验运行期间按固定间隔采集 runtime/metrics，
+// 输出 CSV 或 JSON lines 形式的时间序列，以及整个运行期间的汇总分位数。
+// printMem 只在结束时打印一次 MemStats.Alloc，而像 TestBadTimeout 这样的实验，
+// 需要看到协程数和堆大小随时间的变化趋势。
+package rtsample
+
+import (
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// Metric 描述一个要采集的指标，Names 按优先级排列，使用当前 Go 版本支持的第一个，
+// 因为部分指标在不同版本中改过名字
+type Metric struct {
+	Key   string
+	Names []string
+}
+
+// DefaultMetrics 为默认采集的指标
+var DefaultMetrics = []Metric{
+	{Key: "heap_goal", Names: []string{"/gc/heap/goal:bytes"}},
+	{Key: "heap_live", Names: []string{"/gc/heap/live:bytes", "/memory/classes/heap/objects:bytes"}},
+	{Key: "heap_objects", Names: []string{"/gc/heap/objects:objects"}},
+	{Key: "gc_cycles", Names: []string{"/gc/cycles/total:gc-cycles"}},
+	{Key: "goroutines", Names: []string{"/sched/goroutines:goroutines"}},
+	{Key: "mutex_wait", Names: []string{"/sync/mutex/wait/total:seconds"}},
+	{Key: "gc_pauses", Names: []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}},
+	{Key: "sched_latency", Names: []string{"/sched/latencies:seconds"}},
+}
+
+// Point 是一次采样的结果。
+// 标量指标记录采样时刻的值；直方图指标记录本次采样间隔内新增的样本数和分位数，
+// 键为 <key>_count、<key>_p50、<key>_p99
+type Point struct {
+	Time   time.Time
+	Values map[string]float64
+}
+
+// Sampler 在后台协程中周期性采样
+type Sampler struct {
+	interval time.Duration
+	metrics  []Metric
+	samples  []metrics.Sample
+	// keys 与 samples 一一对应，记录每个指标的 Key
+	keys []string
+
+	mu     sync.Mutex
+	points []Point
+	first  map[string]*metrics.Float64Histogram
+	prev   map[string]*metrics.Float64Histogram
+	start  time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	// stopOnce 保证重复调用 Stop 不会重复关闭 stop，并返回同一个 Series
+	stopOnce sync.Once
+	series   *Series
+}
+
+// Start 以 interval 为间隔开始采样，ms 为空时使用 DefaultMetrics
+func Start(interval time.Duration, ms ...Metric) *Sampler {
+	if len(ms) == 0 {
+		ms = DefaultMetrics
+	}
+	s := &Sampler{
+		interval: interval,
+		metrics:  ms,
+		first:    make(map[string]*metrics.Float64Histogram),
+		prev:     make(map[string]*metrics.Float64Histogram),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	supported := make(map[string]bool)
+	for _, d := range metrics.All() {
+		supported[d.Name] = true
+	}
+	for _, m := range ms {
+		for _, name := range m.Names {
+			if supported[name] {
+				s.samples = append(s.samples, metrics.Sample{Name: name})
+				s.keys = append(s.keys, m.Key)
+				break
+			}
+		}
+	}
+	s.start = time.Now()
+	s.sample(s.start)
+	go s.loop()
+	return s
+}
+
+// Run 在采样期间执行 fn，返回采集到的时间序列
+func Run(interval time.Duration, fn func()) *Series {
+	s := Start(interval)
+	fn()
+	return s.Stop()
+}
+
+func (s *Sampler) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.sample(now)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Stop 停止采样，并在停止前补采最后一个点。可以重复调用，返回的都是同一个 Series。
+func (s *Sampler) Stop() *Series {
+	s.stopOnce.Do(func() { s.series = s.finish() })
+	return s.series
+}
+
+func (s *Sampler) finish() *Series {
+	close(s.stop)
+	<-s.done
+	s.sample(time.Now())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := &Series{
+		Start:  s.start,
+		Points: s.points,
+		Hists:  make(map[string]*metrics.Float64Histogram),
+	}
+	for _, m := range s.metrics {
+		if last, ok := s.prev[m.Key]; ok {
+			series.Hists[m.Key] = sub(last, s.first[m.Key])
+		}
+		if s.has(m.Key) {
+			series.Keys = append(series.Keys, m.Key)
+		}
+	}
+	return series
+}
+
+func (s *Sampler) has(key string) bool {
+	for _, k := range s.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sampler) sample(now time.Time) {
+	metrics.Read(s.samples)
+	p := Point{Time: now, Values: make(map[string]float64, len(s.samples))}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, sample := range s.samples {
+		key := s.keys[i]
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			p.Values[key] = float64(sample.Value.Uint64())
+		case metrics.KindFloat64:
+			p.Values[key] = sample.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			// metrics.Read 会复用直方图的内存，需要拷贝一份
+			h := clone(sample.Value.Float64Histogram())
+			if prev, ok := s.prev[key]; ok {
+				d := sub(h, prev)
+				p.Values[key+"_count"] = float64(total(d))
+				p.Values[key+"_p50"] = Percentile(d, 0.5)
+				p.Values[key+"_p99"] = Percentile(d, 0.99)
+			} else {
+				s.first[key] = h
+			}
+			s.prev[key] = h
+		}
+	}
+	s.points = append(s.points, p)
+}
+
+func clone(h *metrics.Float64Histogram) *metrics.Float64Histogram {
+	return &metrics.Float64Histogram{
+		Counts:  append([]uint64(nil), h.Counts...),
+		Buckets: h.Buckets,
+	}
+}
+
+// sub 计算 a - b，两者来自同一个指标，桶的划分相同
+func sub(a, b *metrics.Float64Histogram) *metrics.Float64Histogram {
+	d := clone(a)
+	if b == nil {
+		return d
+	}
+	for i := range d.Counts {
+		if i < len(b.Counts) {
+			d.Counts[i] -= b.Counts[i]
+		}
+	}
+	return d
+}
+
+func total(h *metrics.Float64Histogram) uint64 {
+	var n uint64
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package rtsample
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var sink [][]byte
+	series := Run(5*time.Millisecond, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				time.Sleep(30 * time.Millisecond)
+			}()
+		}
+		for i := 0; i < 1000; i++ {
+			sink = append(sink, make([]byte, 64*1024))
+			if i%100 == 0 {
+				sink = nil
+			}
+		}
+		wg.Wait()
+	})
+	series.Summary(os.Stdout)
+	if len(series.Points) < 3 {
+		t.Fatalf("got %d points, want at least 3", len(series.Points))
+	}
+	st, ok := series.Stat("goroutines")
+	if !ok || st.Max < 100 {
+		t.Fatalf("goroutines max = %v, want >= 100", st.Max)
+	}
+
+	var csv bytes.Buffer
+	if err := series.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != len(series.Points)+1 || !strings.HasPrefix(lines[0], "elapsed_s,heap_goal") {
+		t.Fatalf("unexpected csv header %q with %d lines", lines[0], len(lines))
+	}
+
+	var js bytes.Buffer
+	if err := series.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var first map[string]float64
+	if err := json.Unmarshal([]byte(strings.SplitN(js.String(), "\n", 2)[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := first["goroutines"]; !ok {
+		t.Fatalf("missing goroutines in %v", first)
+	}
+}
+
+func TestStopTwice(t *testing.T) {
+	s := Start(time.Millisecond)
+	first := s.Stop()
+	if second := s.Stop(); second != first {
+		t.Fatal("second Stop returned a different Series")
+	}
+}
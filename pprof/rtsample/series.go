@@ -0,0 +1,183 @@
+package rtsample
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Series 是一次采样得到的时间序列
+type Series struct {
+	Start  time.Time
+	Keys   []string
+	Points []Point
+	// Hists 为直方图指标在整个采样期间的增量
+	Hists map[string]*metrics.Float64Histogram
+}
+
+// columns 返回所有点中出现过的列名，标量指标按 Keys 的顺序排在前面
+func (s *Series) columns() []string {
+	seen := make(map[string]bool)
+	var cols []string
+	for _, k := range s.Keys {
+		if _, ok := s.Hists[k]; ok {
+			continue
+		}
+		seen[k] = true
+		cols = append(cols, k)
+	}
+	var extra []string
+	for _, p := range s.Points {
+		for k := range p.Values {
+			if !seen[k] {
+				seen[k] = true
+				extra = append(extra, k)
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(cols, extra...)
+}
+
+// WriteCSV 输出 CSV，第一列为相对开始时间的秒数
+func (s *Series) WriteCSV(w io.Writer) error {
+	cols := s.columns()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"elapsed_s"}, cols...)); err != nil {
+		return err
+	}
+	for _, p := range s.Points {
+		row := []string{strconv.FormatFloat(p.Time.Sub(s.Start).Seconds(), 'f', 3, 64)}
+		for _, c := range cols {
+			if v, ok := p.Values[c]; ok {
+				row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON 输出 JSON lines，每行一个采样点
+func (s *Series) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, p := range s.Points {
+		line := make(map[string]interface{}, len(p.Values)+1)
+		for k, v := range p.Values {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				line[k] = v
+			}
+		}
+		line["elapsed_s"] = p.Time.Sub(s.Start).Seconds()
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stat 是标量指标在整个采样期间的统计
+type Stat struct {
+	Min, Mean, Max, Last float64
+}
+
+// Stat 返回标量指标 key 的统计，不存在时 ok 为 false
+func (s *Series) Stat(key string) (st Stat, ok bool) {
+	n := 0
+	for _, p := range s.Points {
+		v, exist := p.Values[key]
+		if !exist {
+			continue
+		}
+		if n == 0 || v < st.Min {
+			st.Min = v
+		}
+		if n == 0 || v > st.Max {
+			st.Max = v
+		}
+		st.Mean += v
+		st.Last = v
+		n++
+	}
+	if n == 0 {
+		return st, false
+	}
+	st.Mean /= float64(n)
+	return st, true
+}
+
+// Percentile 返回直方图的 q 分位数（0 <= q <= 1），取所在桶的上界，
+// 最后一个桶上界为 +Inf 时取下界。直方图为空时返回 NaN
+func Percentile(h *metrics.Float64Histogram, q float64) float64 {
+	n := total(h)
+	if n == 0 {
+		return math.NaN()
+	}
+	rank := uint64(math.Ceil(q * float64(n)))
+	if rank == 0 {
+		rank = 1
+	}
+	var cum uint64
+	for i, c := range h.Counts {
+		cum += c
+		if cum >= rank {
+			if hi := h.Buckets[i+1]; !math.IsInf(hi, 1) {
+				return hi
+			}
+			return h.Buckets[i]
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// Summary 输出每个标量指标的 min/mean/max，以及每个直方图指标的样本数和 p50/p90/p99/max
+func (s *Series) Summary(w io.Writer) {
+	var elapsed time.Duration
+	if len(s.Points) > 0 {
+		elapsed = s.Points[len(s.Points)-1].Time.Sub(s.Start)
+	}
+	fmt.Fprintf(w, "%d samples over %v\n", len(s.Points), elapsed.Round(time.Millisecond))
+	for _, k := range s.Keys {
+		if h, ok := s.Hists[k]; ok {
+			fmt.Fprintf(w, "  %-14s n=%d p50=%s p90=%s p99=%s max=%s\n", k, total(h),
+				formatSeconds(Percentile(h, 0.5)), formatSeconds(Percentile(h, 0.9)),
+				formatSeconds(Percentile(h, 0.99)), formatSeconds(Percentile(h, 1)))
+			continue
+		}
+		st, ok := s.Stat(k)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "  %-14s min=%s mean=%s max=%s last=%s\n", k,
+			format(k, st.Min), format(k, st.Mean), format(k, st.Max), format(k, st.Last))
+	}
+}
+
+func format(key string, v float64) string {
+	switch {
+	case strings.HasPrefix(key, "heap_goal"), strings.HasPrefix(key, "heap_live"):
+		return fmt.Sprintf("%.2fMB", v/1024/1024)
+	case key == "mutex_wait":
+		return formatSeconds(v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatSeconds(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return time.Duration(v * float64(time.Second)).String()
+}
@@ -0,0 +1,125 @@
+package stackdump
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Group 是状态和调用栈完全相同的一组 goroutine
+type Group struct {
+	State      string
+	WaitReason string
+	Frames     []Frame
+	CreatedBy  *Frame
+	IDs        []int
+	// MinWait 和 MaxWait 为组内阻塞时长的范围
+	MinWait time.Duration
+	MaxWait time.Duration
+}
+
+// Count 返回组内 goroutine 的数量
+func (g *Group) Count() int { return len(g.IDs) }
+
+// Location 返回栈顶第一个非标准库的帧，也就是业务代码中阻塞的位置，
+// 找不到时返回栈顶帧
+func (g *Group) Location() Frame {
+	for _, f := range g.Frames {
+		if !isStd(f) {
+			return f
+		}
+	}
+	if len(g.Frames) > 0 {
+		return g.Frames[0]
+	}
+	return Frame{}
+}
+
+// String 返回形如 "1000 × blocked on chan send at timeout_test.go:13" 的摘要
+func (g *Group) String() string {
+	what := g.State
+	if g.WaitReason != "" {
+		what = "blocked on " + g.WaitReason
+	}
+	s := fmt.Sprintf("%d × %s at %s", g.Count(), what, g.Location().Short())
+	if g.MaxWait > 0 {
+		s += fmt.Sprintf(" (%v-%v)", g.MinWait, g.MaxWait)
+	}
+	return s
+}
+
+// GroupBy 将状态、阻塞原因、调用栈和创建位置都相同的 goroutine 归为一组，
+// 结果按数量从多到少排序
+func GroupBy(gs []*Goroutine) []*Group {
+	index := make(map[string]*Group)
+	var groups []*Group
+	for _, g := range gs {
+		k := key(g)
+		grp, ok := index[k]
+		if !ok {
+			grp = &Group{
+				State:      g.State,
+				WaitReason: g.WaitReason,
+				Frames:     g.Frames,
+				CreatedBy:  g.CreatedBy,
+				MinWait:    g.Wait,
+			}
+			index[k] = grp
+			groups = append(groups, grp)
+		}
+		grp.IDs = append(grp.IDs, g.ID)
+		if g.Wait < grp.MinWait {
+			grp.MinWait = g.Wait
+		}
+		if g.Wait > grp.MaxWait {
+			grp.MaxWait = g.Wait
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count() > groups[j].Count()
+	})
+	return groups
+}
+
+// Summary 将分组结果逐行写入 w，verbose 为 true 时附带完整调用栈
+func Summary(w io.Writer, groups []*Group, verbose bool) {
+	for _, g := range groups {
+		fmt.Fprintln(w, g)
+		if !verbose {
+			continue
+		}
+		for _, f := range g.Frames {
+			fmt.Fprintf(w, "\t%s\n\t\t%s:%d\n", f.Func, f.File, f.Line)
+		}
+		if g.CreatedBy != nil {
+			fmt.Fprintf(w, "\tcreated by %s\n\t\t%s:%d\n", g.CreatedBy.Func, g.CreatedBy.File, g.CreatedBy.Line)
+		}
+	}
+}
+
+// 参数值（指针地址等）因协程而异，不参与分组；阻塞时长也不参与分组
+func key(g *Goroutine) string {
+	var b strings.Builder
+	b.WriteString(g.State)
+	b.WriteByte('|')
+	b.WriteString(g.WaitReason)
+	for _, f := range g.Frames {
+		fmt.Fprintf(&b, "|%s %s:%d", f.Func, f.File, f.Line)
+	}
+	if g.CreatedBy != nil {
+		fmt.Fprintf(&b, "|created by %s %s:%d", g.CreatedBy.Func, g.CreatedBy.File, g.CreatedBy.Line)
+	}
+	return b.String()
+}
+
+var goroot = runtime.GOROOT()
+
+func isStd(f Frame) bool {
+	if goroot != "" && strings.HasPrefix(f.File, goroot+"/src/") {
+		return true
+	}
+	return strings.HasPrefix(f.Func, "runtime.") || strings.HasPrefix(f.Func, "internal/")
+}
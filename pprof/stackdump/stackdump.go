@@ -0,0 +1,190 @@
+// Package stackdump 将 runtime.Stack(buf, true) 或 goroutine profile(debug=2) 的文本输出
+// 解析为结构化的 goroutine 记录，并把调用栈相同的 goroutine 归并计数。
+// 例如 TestBadTimeout 中 1000 个阻塞在 dobadthing 的协程，会被归并为一行：
+// 1000 × blocked on chan send at timeout_test.go:13
+package stackdump
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frame 是调用栈中的一帧
+type Frame struct {
+	Func string // 完整函数名，如 highPerformance/concurrency.dobadthing
+	Args string // 括号内的参数，如 0xc000010000 或 ...
+	File string
+	Line int
+}
+
+// Short 返回 "文件名:行号" 形式的位置
+func (f Frame) Short() string {
+	return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+}
+
+// Goroutine 是一个 goroutine 的结构化记录
+type Goroutine struct {
+	ID int
+	// State 为 running、runnable、syscall 等调度状态，阻塞中的协程统一为 waiting
+	State string
+	// WaitReason 为阻塞原因，如 chan send、chan receive、select、sync.Mutex.Lock，非阻塞时为空
+	WaitReason string
+	// Wait 为阻塞时长，traceback 中仅在阻塞超过 1 分钟时给出，精度为分钟
+	Wait           time.Duration
+	LockedToThread bool
+	Frames         []Frame
+	// CreatedBy 为创建该协程的 go 语句所在位置，main goroutine 为 nil
+	CreatedBy *Frame
+	// CreatorID 为创建者 goroutine 的 ID，旧版本的 traceback 不包含该信息时为 0
+	CreatorID int
+}
+
+// 这些状态来自 runtime/traceback.go 中的 gStatusStrings，其余的都是 waitReason
+var states = map[string]bool{
+	"idle":      true,
+	"runnable":  true,
+	"running":   true,
+	"syscall":   true,
+	"dead":      true,
+	"copystack": true,
+	"preempted": true,
+}
+
+// Parse 解析 goroutine dump 文本，无法识别的行会被忽略，
+// 因此可以直接传入混杂了其他日志的 panic 输出。
+func Parse(r io.Reader) ([]*Goroutine, error) {
+	var (
+		gs  []*Goroutine
+		cur *Goroutine
+		// pending 为已读取函数行、等待文件行的帧
+		pending *Frame
+		created bool
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			g, ok := parseHeader(line)
+			if !ok {
+				continue
+			}
+			cur, pending = g, nil
+			gs = append(gs, g)
+		case cur == nil:
+		case strings.TrimSpace(line) == "":
+			cur, pending = nil, nil
+		case strings.HasPrefix(line, "\t"):
+			if pending == nil {
+				continue
+			}
+			pending.File, pending.Line = parseLocation(strings.TrimSpace(line))
+			if created {
+				cur.CreatedBy = pending
+			} else {
+				cur.Frames = append(cur.Frames, *pending)
+			}
+			pending = nil
+		case strings.HasPrefix(line, "created by "):
+			f := &Frame{Func: strings.TrimPrefix(line, "created by ")}
+			if i := strings.Index(f.Func, " in goroutine "); i >= 0 {
+				cur.CreatorID, _ = strconv.Atoi(f.Func[i+len(" in goroutine "):])
+				f.Func = f.Func[:i]
+			}
+			pending, created = f, true
+		case strings.HasPrefix(line, "...") || strings.HasPrefix(line, "…"):
+			// ...additional frames elided...
+		default:
+			f := parseFunc(line)
+			pending, created = &f, false
+		}
+	}
+	return gs, sc.Err()
+}
+
+// ParseBytes 是 Parse 的便捷形式
+func ParseBytes(b []byte) ([]*Goroutine, error) {
+	return Parse(bytes.NewReader(b))
+}
+
+// Dump 获取当前进程所有 goroutine 的调用栈并解析
+func Dump() []*Goroutine {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	gs, _ := ParseBytes(buf)
+	return gs
+}
+
+// parseHeader 解析形如 "goroutine 18 [chan send, 3 minutes, locked to thread]:" 的行，
+// GOTRACEBACK=system 时 ID 与 [ 之间还会有 gp=... m=... 等字段
+func parseHeader(line string) (*Goroutine, bool) {
+	open, end := strings.IndexByte(line, '['), strings.LastIndex(line, "]:")
+	if open < 0 || end < open {
+		return nil, false
+	}
+	fields := strings.Fields(line[len("goroutine "):open])
+	if len(fields) == 0 {
+		return nil, false
+	}
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, false
+	}
+	g := &Goroutine{ID: id}
+	for i, attr := range strings.Split(line[open+1:end], ", ") {
+		switch {
+		case i == 0:
+			if states[attr] {
+				g.State = attr
+			} else {
+				g.State, g.WaitReason = "waiting", attr
+			}
+		case attr == "locked to thread":
+			g.LockedToThread = true
+		case strings.HasSuffix(attr, " minutes") || strings.HasSuffix(attr, " minute"):
+			if m, err := strconv.Atoi(strings.Fields(attr)[0]); err == nil {
+				g.Wait = time.Duration(m) * time.Minute
+			}
+		}
+	}
+	return g, true
+}
+
+// parseFunc 解析形如 "main.bad(0xc000012345, ...)" 的函数行
+func parseFunc(line string) Frame {
+	if i := strings.LastIndexByte(line, '('); i > 0 && strings.HasSuffix(line, ")") {
+		return Frame{Func: line[:i], Args: line[i+1 : len(line)-1]}
+	}
+	return Frame{Func: line}
+}
+
+// parseLocation 解析形如 "/path/to/file.go:13 +0x3d" 的位置行
+func parseLocation(s string) (string, int) {
+	if i := strings.LastIndex(s, " +0x"); i >= 0 {
+		s = s[:i]
+	}
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, 0
+	}
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return s, 0
+	}
+	return s[:i], line
+}
@@ -0,0 +1,99 @@
+package stackdump
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `goroutine 1 [running]:
+main.main()
+	/tmp/sd/main.go:7 +0x148
+
+goroutine 7 [sync.Mutex.Lock, 2 minutes, locked to thread]:
+sync.(*Mutex).Lock(...)
+	/usr/local/go/src/sync/mutex.go:46
+main.main.func1()
+	/tmp/sd/main.go:4 +0x2c
+created by main.main in goroutine 1
+	/tmp/sd/main.go:4 +0x8b
+
+goroutine 8 [chan send, 3 minutes]:
+main.bad(0xc000010000)
+	/tmp/sd/main.go:3 +0x1d
+created by main.main in goroutine 1
+	/tmp/sd/main.go:5 +0x98
+
+goroutine 9 [chan send, 5 minutes]:
+main.bad(0xc000010010)
+	/tmp/sd/main.go:3 +0x1d
+created by main.main in goroutine 1
+	/tmp/sd/main.go:5 +0x98
+`
+
+func TestParse(t *testing.T) {
+	gs, err := ParseBytes([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gs) != 4 {
+		t.Fatalf("got %d goroutines, want 4", len(gs))
+	}
+	g := gs[1]
+	if g.ID != 7 || g.State != "waiting" || g.WaitReason != "sync.Mutex.Lock" || g.Wait != 2*time.Minute || !g.LockedToThread {
+		t.Fatalf("unexpected header: %+v", g)
+	}
+	if len(g.Frames) != 2 || g.Frames[1].Func != "main.main.func1" || g.Frames[1].Line != 4 {
+		t.Fatalf("unexpected frames: %+v", g.Frames)
+	}
+	if g.CreatedBy == nil || g.CreatedBy.Func != "main.main" || g.CreatorID != 1 {
+		t.Fatalf("unexpected created by: %+v %d", g.CreatedBy, g.CreatorID)
+	}
+	if gs[0].State != "running" || gs[0].CreatedBy != nil {
+		t.Fatalf("unexpected main goroutine: %+v", gs[0])
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	gs, _ := ParseBytes([]byte(sample))
+	groups := GroupBy(gs)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+	want := "2 × blocked on chan send at main.go:3 (3m0s-5m0s)"
+	if s := groups[0].String(); s != want {
+		t.Fatalf("got %q, want %q", s, want)
+	}
+}
+
+func bad(done chan bool) {
+	done <- true
+}
+
+// 与 TestBadTimeout 相同的泄漏场景，dump 后应能归并为一组。
+// 测试结束时接收这些通道放走协程，否则 -count 多次运行时前几次的协程会被合并到同一组中
+func TestDump(t *testing.T) {
+	const n = 100
+	chans := make([]chan bool, n)
+	for i := range chans {
+		chans[i] = make(chan bool)
+		go bad(chans[i])
+	}
+	defer func() {
+		for _, ch := range chans {
+			<-ch
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	for _, g := range GroupBy(Dump()) {
+		if g.WaitReason == "chan send" && strings.HasSuffix(g.Location().Func, ".bad") {
+			if g.Count() != n {
+				t.Fatalf("got %d goroutines, want %d", g.Count(), n)
+			}
+			Summary(os.Stdout, []*Group{g}, true)
+			return
+		}
+	}
+	t.Fatal("leaked goroutines not found")
+}
@@ -0,0 +1,275 @@
+// Package hpbin 是一种紧凑的二进制编码，用于 Student、Config 这类只有基本类型字段的扁平结构体。
+// JSON 需要输出字段名、把数字格式化为十进制文本再解析回来，[1024]byte 这样的数组每个元素都要写成 "0,"；
+// hpbin 不写字段名，按字段的声明顺序依次编码：
+//
+//   - 整数使用 varint，有符号整数先做 zigzag 变换，小的数只占 1 个字节
+//   - 浮点数使用定长的小端序 IEEE 754
+//   - 字符串和 []byte 先写 varint 长度，再写原始字节
+//   - 定长数组（如 Remark [1024]byte）去掉末尾的 0 后按 []byte 编码，解码时补齐 0
+//
+// 格式中没有字段名和类型信息，编码和解码两端必须使用相同的字段顺序；
+// 需要兼容旧版本的数据时，应当在记录前加上版本号，由调用方按版本选择解码方式。
+//
+// 解码使用 Reader，遇到第一个错误后不再读取输入，调用方只需要在最后检查一次 End 的返回值。
+// 除 String 外，Reader 的方法都不分配内存，解码到从对象池中取出的对象时可以做到零分配。
+package hpbin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+// AppendUvarint 追加无符号整数
+func AppendUvarint(dst []byte, u uint64) []byte {
+	for u >= 0x80 {
+		dst = append(dst, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(dst, byte(u))
+}
+
+// AppendVarint 追加有符号整数，zigzag 变换使绝对值小的负数同样只占很少的字节
+func AppendVarint(dst []byte, n int64) []byte {
+	return AppendUvarint(dst, uint64(n<<1)^uint64(n>>63))
+}
+
+// AppendBool 追加一个字节，0 或 1
+func AppendBool(dst []byte, b bool) []byte {
+	if b {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+// AppendFloat64 追加 8 字节小端序的 IEEE 754 表示
+func AppendFloat64(dst []byte, f float64) []byte {
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(f))
+}
+
+// AppendFloat32 追加 4 字节小端序的 IEEE 754 表示
+func AppendFloat32(dst []byte, f float32) []byte {
+	return binary.LittleEndian.AppendUint32(dst, math.Float32bits(f))
+}
+
+// AppendString 追加长度和字符串的内容
+func AppendString(dst []byte, s string) []byte {
+	return append(AppendUvarint(dst, uint64(len(s))), s...)
+}
+
+// AppendBytes 追加长度和 b 的内容。nil 与空切片的编码相同
+func AppendBytes(dst []byte, b []byte) []byte {
+	return append(AppendUvarint(dst, uint64(len(b))), b...)
+}
+
+// AppendArray 追加定长数组的内容，调用方传入 a[:]。
+// 末尾的 0 不会被写入，Remark [1024]byte 中只有开头几十个字节有内容时，编码后也只有几十个字节
+func AppendArray(dst []byte, a []byte) []byte {
+	n := len(a)
+	// 一次比较 8 个字节，Remark [1024]byte 全为 0 时逐字节比较需要约 400ns
+	for n >= 8 && binary.LittleEndian.Uint64(a[n-8:n]) == 0 {
+		n -= 8
+	}
+	for n > 0 && a[n-1] == 0 {
+		n--
+	}
+	return AppendBytes(dst, a[:n])
+}
+
+// UvarintSize 返回 AppendUvarint(nil, u) 的长度，可用于预先分配缓冲区
+func UvarintSize(u uint64) int {
+	return (bits.Len64(u|1) + 6) / 7
+}
+
+// FormatError 表示输入被截断或者不是合法的编码
+type FormatError struct {
+	Msg    string
+	Offset int
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("hpbin: %s at offset %d", e.Msg, e.Offset)
+}
+
+// Reader 从字节切片中依次读取各个字段，零值经过 Reset 后可用
+type Reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// Reset 开始读取 data
+func (r *Reader) Reset(data []byte) {
+	*r = Reader{data: data}
+}
+
+// Err 返回遇到的第一个错误
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Fail 记录错误，已有错误时忽略
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Reader) fail(msg string) {
+	r.Fail(&FormatError{Msg: msg, Offset: r.pos})
+}
+
+// End 检查输入已经读完，返回遇到的第一个错误
+func (r *Reader) End() error {
+	if r.err == nil && r.pos != len(r.data) {
+		r.fail(fmt.Sprintf("%d bytes of trailing data", len(r.data)-r.pos))
+	}
+	return r.err
+}
+
+// Uvarint 读取无符号整数
+func (r *Reader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	// 单字节是最常见的情况
+	if r.pos < len(r.data) && r.data[r.pos] < 0x80 {
+		u := uint64(r.data[r.pos])
+		r.pos++
+		return u
+	}
+	u, n := binary.Uvarint(r.data[r.pos:])
+	switch {
+	case n == 0:
+		r.fail("unexpected end of input")
+		return 0
+	case n < 0:
+		r.fail("varint overflows 64 bits")
+		return 0
+	}
+	r.pos += n
+	return u
+}
+
+// Varint 读取有符号整数
+func (r *Reader) Varint() int64 {
+	u := r.Uvarint()
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// Int 读取有符号整数并检查它能否用 bits 位表示，bits 为 0 时表示 int 的位数
+func (r *Reader) Int(bits int) int64 {
+	start := r.pos
+	n := r.Varint()
+	if bits == 0 {
+		bits = strconv.IntSize
+	}
+	if bits < 64 && (n < -1<<(bits-1) || n >= 1<<(bits-1)) {
+		r.Fail(&FormatError{Msg: fmt.Sprintf("value %d overflows int%d", n, bits), Offset: start})
+		return 0
+	}
+	return n
+}
+
+// Uint 读取无符号整数并检查它能否用 bits 位表示，bits 为 0 时表示 uint 的位数
+func (r *Reader) Uint(bits int) uint64 {
+	start := r.pos
+	u := r.Uvarint()
+	if bits == 0 {
+		bits = strconv.IntSize
+	}
+	if bits < 64 && u >= 1<<bits {
+		r.Fail(&FormatError{Msg: fmt.Sprintf("value %d overflows uint%d", u, bits), Offset: start})
+		return 0
+	}
+	return u
+}
+
+// Bool 读取一个字节，只接受 0 和 1
+func (r *Reader) Bool() bool {
+	b := r.next(1)
+	if b == nil {
+		return false
+	}
+	if b[0] > 1 {
+		r.pos--
+		r.fail(fmt.Sprintf("invalid bool %d", b[0]))
+		return false
+	}
+	return b[0] == 1
+}
+
+// Float64 读取 8 字节的浮点数
+func (r *Reader) Float64() float64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+// Float32 读取 4 字节的浮点数
+func (r *Reader) Float32() float32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+// next 返回接下来的 n 个字节，输入不足时记录错误并返回 nil
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if uint64(len(r.data)-r.pos) < uint64(n) {
+		r.fail("unexpected end of input")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// Bytes 读取长度和内容，返回的切片引用输入，调用方需要保留时应当复制
+func (r *Reader) Bytes() []byte {
+	n := r.Uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail(fmt.Sprintf("length %d exceeds input", n))
+		return nil
+	}
+	return r.next(int(n))
+}
+
+// AppendBytesTo 读取长度和内容并追加到 dst 的末尾，用于解码 []byte 字段：
+// 传入 dst[:0] 可以复用字段原有的空间
+func (r *Reader) AppendBytesTo(dst []byte) []byte {
+	return append(dst, r.Bytes()...)
+}
+
+// String 读取字符串。内容与 old 相同时直接返回 old，不分配内存：
+// 从对象池中取出的对象上一次解码得到的值往往与这次相同（如固定的几个服务名），写成 s.Name = r.String(s.Name)
+func (r *Reader) String(old string) string {
+	b := r.Bytes()
+	if string(b) == old {
+		return old
+	}
+	return string(b)
+}
+
+// Array 读取 AppendArray 写入的内容到定长数组 a[:]，剩余的部分补 0
+func (r *Reader) Array(a []byte) {
+	b := r.Bytes()
+	if len(b) > len(a) {
+		r.pos -= len(b)
+		r.fail(fmt.Sprintf("array of length %d exceeds [%d]byte", len(b), len(a)))
+		return
+	}
+	n := copy(a, b)
+	clear(a[n:])
+}
@@ -0,0 +1,147 @@
+package hpbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// varint 与 encoding/binary 的格式相同
+func FuzzVarint(f *testing.F) {
+	for _, v := range []int64{0, 1, -1, 63, -64, 64, 127, 128, math.MaxInt64, math.MinInt64} {
+		f.Add(v)
+	}
+	f.Fuzz(func(t *testing.T, n int64) {
+		if got, want := AppendVarint(nil, n), binary.AppendVarint(nil, n); !bytes.Equal(got, want) {
+			t.Fatalf("AppendVarint(%d) = %x, want %x", n, got, want)
+		}
+		u := uint64(n)
+		b := AppendUvarint(nil, u)
+		if want := binary.AppendUvarint(nil, u); !bytes.Equal(b, want) {
+			t.Fatalf("AppendUvarint(%d) = %x, want %x", u, b, want)
+		}
+		if UvarintSize(u) != len(b) {
+			t.Fatalf("UvarintSize(%d) = %d, want %d", u, UvarintSize(u), len(b))
+		}
+	})
+}
+
+type record struct {
+	s    string
+	b    []byte
+	a    [16]byte
+	i    int64
+	i8   int8
+	u    uint64
+	u16  uint16
+	f    float64
+	f32  float32
+	bool bool
+}
+
+func (v *record) append(dst []byte) []byte {
+	dst = AppendString(dst, v.s)
+	dst = AppendBytes(dst, v.b)
+	dst = AppendArray(dst, v.a[:])
+	dst = AppendVarint(dst, v.i)
+	dst = AppendVarint(dst, int64(v.i8))
+	dst = AppendUvarint(dst, v.u)
+	dst = AppendUvarint(dst, uint64(v.u16))
+	dst = AppendFloat64(dst, v.f)
+	dst = AppendFloat32(dst, v.f32)
+	return AppendBool(dst, v.bool)
+}
+
+func (v *record) decode(r *Reader) {
+	v.s = r.String(v.s)
+	v.b = r.AppendBytesTo(v.b[:0])
+	r.Array(v.a[:])
+	v.i = r.Varint()
+	v.i8 = int8(r.Int(8))
+	v.u = r.Uvarint()
+	v.u16 = uint16(r.Uint(16))
+	v.f = r.Float64()
+	v.f32 = r.Float32()
+	v.bool = r.Bool()
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("sungn", []byte{1, 2}, []byte("remark"), int64(-300), int8(-1), uint64(1<<40), uint16(65535), 1.5, true)
+	f.Add("", []byte(nil), []byte{0, 0, 1}, int64(0), int8(0), uint64(0), uint16(0), math.Inf(-1), false)
+	f.Fuzz(func(t *testing.T, s string, b, a []byte, i int64, i8 int8, u uint64, u16 uint16, fl float64, bo bool) {
+		in := record{s: s, b: b, i: i, i8: i8, u: u, u16: u16, f: fl, f32: float32(fl), bool: bo}
+		copy(in.a[:], a)
+		data := in.append(nil)
+
+		// 解码到上一次使用过的对象中，旧的值不应当残留
+		out := record{s: "old", b: []byte("old bytes"), a: [16]byte{15: 1}}
+		var r Reader
+		r.Reset(data)
+		out.decode(&r)
+		if err := r.End(); err != nil {
+			t.Fatal(err)
+		}
+		if out.s != in.s || !bytes.Equal(out.b, in.b) || out.a != in.a || out.i != in.i || out.i8 != in.i8 ||
+			out.u != in.u || out.u16 != in.u16 || math.Float64bits(out.f) != math.Float64bits(in.f) ||
+			math.Float32bits(out.f32) != math.Float32bits(in.f32) || out.bool != in.bool {
+			t.Fatalf("got %+v, want %+v", out, in)
+		}
+
+		// 任何截断的输入都应当报错，而不是 panic 或返回部分结果
+		for n := 0; n < len(data); n++ {
+			r.Reset(data[:n])
+			out.decode(&r)
+			if r.End() == nil {
+				t.Fatalf("truncated input %x decoded without error", data[:n])
+			}
+		}
+	})
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		read func(r *Reader)
+	}{
+		{"trailing", []byte{1, 2}, func(r *Reader) { r.Uvarint() }},
+		{"overflow", bytes.Repeat([]byte{0xff}, 11), func(r *Reader) { r.Uvarint() }},
+		{"int8", AppendVarint(nil, 128), func(r *Reader) { r.Int(8) }},
+		{"uint16", AppendUvarint(nil, 1<<16), func(r *Reader) { r.Uint(16) }},
+		{"bool", []byte{2}, func(r *Reader) { r.Bool() }},
+		{"length", []byte{5, 'a'}, func(r *Reader) { r.Bytes() }},
+		{"huge length", AppendUvarint(nil, math.MaxUint64), func(r *Reader) { r.Bytes() }},
+		{"array", AppendString(nil, "12345"), func(r *Reader) { r.Array(make([]byte, 4)) }},
+	}
+	for _, tt := range tests {
+		var r Reader
+		r.Reset(tt.data)
+		tt.read(&r)
+		var fe *FormatError
+		if err := r.End(); !errors.As(err, &fe) {
+			t.Errorf("%s: got %v, want *FormatError", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeAllocs(t *testing.T) {
+	in := record{s: "sungn", b: []byte("bytes"), i: -1, f: 1}
+	copy(in.a[:], "remark")
+	data := in.append(nil)
+	var out record
+	var r Reader
+	r.Reset(data)
+	out.decode(&r)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(data)
+		out.decode(&r)
+		if r.End() != nil {
+			t.Fatal(r.Err())
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("decode into a reused record allocates %v times", allocs)
+	}
+}
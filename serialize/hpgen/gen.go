@@ -0,0 +1,458 @@
+package hpgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"highPerformance/serialize/hpjson"
+)
+
+const hpjsonPath = "highPerformance/serialize/hpjson"
+
+// Generate 为包中所有带注释的结构体生成代码，返回输出文件名（不含目录）到文件内容的映射
+func (p *Package) Generate() (map[string][]byte, error) {
+	byFile := make(map[string][]*Struct)
+	for _, s := range p.Structs {
+		out := OutputFile(s.File)
+		byFile[out] = append(byFile[out], s)
+	}
+	files := make(map[string][]byte)
+	for out, structs := range byFile {
+		g := &generator{pkg: p, imports: map[string]string{hpjsonPath: "hpjson"}}
+		for _, s := range structs {
+			g.structCode(s)
+		}
+		src, err := g.file()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", out, err)
+		}
+		files[out] = src
+	}
+	return files, nil
+}
+
+type generator struct {
+	pkg     *Package
+	imports map[string]string // 导入路径 -> 包名
+	body    bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *generator) file() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by hpgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", g.pkg.Name)
+	// 标准库在前，其他包在后，中间空一行
+	var std, other []string
+	for path := range g.imports {
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") || first == module(g.pkg.Path) {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	for _, path := range std {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	if len(std) > 0 && len(other) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range other {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	b.WriteString(")\n")
+	b.Write(g.body.Bytes())
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, b.Bytes())
+	}
+	return src, nil
+}
+
+// module 返回导入路径的第一段，作为当前模块的名字
+func module(path string) string {
+	first, _, _ := strings.Cut(path, "/")
+	return first
+}
+
+// quote 返回字符串字面量，优先使用不需要转义的反引号形式
+func quote(s string) string {
+	if strings.ContainsAny(s, "`\r") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// typeName 返回在生成的文件中引用 t 的表达式，并记录需要的导入
+func (g *generator) typeName(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg.types {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// kind 描述一个类型的编解码方式
+type kind int
+
+const (
+	fallback   kind = iota // 交给 encoding/json
+	basic                  // 没有自定义方法的布尔、整数、浮点数和字符串
+	bytesKind              // []byte，编码为 base64
+	arrayKind              // 元素为 basic 的数组
+	sliceKind              // 元素为 basic 的切片
+	structKind             // 同一个包中另一个带注释的结构体
+)
+
+func (g *generator) kindOf(t types.Type) kind {
+	if g.hasJSONMethods(t) {
+		if n, ok := t.(*types.Named); ok && g.annotated(n) {
+			return structKind
+		}
+		return fallback
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if basicInfo(u) != nil {
+			return basic
+		}
+	case *types.Slice:
+		if e, ok := u.Elem().(*types.Basic); ok && e.Kind() == types.Uint8 {
+			// 与 encoding/json 相同，元素为字节的切片编码为 base64；只有元素类型恰好是 byte 时才能直接解码
+			return bytesKind
+		}
+		if g.kindOf(u.Elem()) == basic {
+			return sliceKind
+		}
+	case *types.Array:
+		if g.kindOf(u.Elem()) == basic {
+			return arrayKind
+		}
+	}
+	return fallback
+}
+
+func (g *generator) annotated(n *types.Named) bool {
+	for _, s := range g.pkg.Structs {
+		if types.Identical(s.Named, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasJSONMethods 报告 t 或 *t 是否实现了 encoding/json 会优先使用的接口
+func (g *generator) hasJSONMethods(t types.Type) bool {
+	for _, typ := range []types.Type{t, types.NewPointer(t)} {
+		ms := types.NewMethodSet(typ)
+		for _, name := range []string{"MarshalJSON", "UnmarshalJSON", "MarshalText", "UnmarshalText"} {
+			if ms.Lookup(nil, name) != nil {
+				return true
+			}
+			// 非导出的方法不影响 encoding/json，这里只检查导出的方法
+		}
+	}
+	// 带注释的结构体即将拥有生成的方法，但本次加载时生成的文件被排除在外
+	if n, ok := t.(*types.Named); ok && g.annotated(n) {
+		return true
+	}
+	return false
+}
+
+type basicType struct {
+	read  string // Lexer 的方法
+	bits  int
+	conv  string // 编码时转换到的类型
+	apply string // 编码函数
+}
+
+func basicInfo(b *types.Basic) *basicType {
+	switch b.Kind() {
+	case types.Bool:
+		return &basicType{"Bool", 0, "bool", "strconv.AppendBool(dst, %s)"}
+	case types.String:
+		return &basicType{"String", 0, "string", "hpjson.AppendString(dst, %s)"}
+	case types.Int:
+		return &basicType{"Int", 0, "int64", "strconv.AppendInt(dst, %s, 10)"}
+	case types.Int8:
+		return &basicType{"Int", 8, "int64", "strconv.AppendInt(dst, %s, 10)"}
+	case types.Int16:
+		return &basicType{"Int", 16, "int64", "strconv.AppendInt(dst, %s, 10)"}
+	case types.Int32:
+		return &basicType{"Int", 32, "int64", "strconv.AppendInt(dst, %s, 10)"}
+	case types.Int64:
+		return &basicType{"Int", 64, "int64", "strconv.AppendInt(dst, %s, 10)"}
+	case types.Uint:
+		return &basicType{"Uint", 0, "uint64", "strconv.AppendUint(dst, %s, 10)"}
+	case types.Uint8:
+		return &basicType{"Uint", 8, "uint64", "strconv.AppendUint(dst, %s, 10)"}
+	case types.Uint16:
+		return &basicType{"Uint", 16, "uint64", "strconv.AppendUint(dst, %s, 10)"}
+	case types.Uint32:
+		return &basicType{"Uint", 32, "uint64", "strconv.AppendUint(dst, %s, 10)"}
+	case types.Uint64, types.Uintptr:
+		return &basicType{"Uint", 64, "uint64", "strconv.AppendUint(dst, %s, 10)"}
+	case types.Float32:
+		return &basicType{"Float", 32, "float64", ""}
+	case types.Float64:
+		return &basicType{"Float", 64, "float64", ""}
+	}
+	return nil
+}
+
+// conv 返回把 expr 转换为 to 类型的表达式，类型相同时省略转换
+func (g *generator) conv(to types.Type, from string, expr string) string {
+	name := g.typeName(to)
+	if name == from {
+		return expr
+	}
+	return name + "(" + expr + ")"
+}
+
+func (g *generator) structCode(s *Struct) {
+	g.encoder(s)
+	g.decoder(s)
+}
+
+// sizeHint 估计编码结果的长度，作为 MarshalJSON 预分配的容量
+func (g *generator) sizeHint(s *Struct) int {
+	n := 2
+	for _, f := range s.Fields {
+		n += len(f.JSON) + 4
+		switch g.kindOf(f.Type) {
+		case arrayKind:
+			n += int(f.Type.Underlying().(*types.Array).Len()) * 2
+		default:
+			n += 16
+		}
+	}
+	return n
+}
+
+func (g *generator) encoder(s *Struct) {
+	needErr := false
+	for _, f := range s.Fields {
+		switch g.kindOf(f.Type) {
+		case fallback, structKind:
+			needErr = true
+		case basic, arrayKind, sliceKind:
+			if elemBasic(f.Type).read == "Float" {
+				needErr = true
+			}
+		}
+	}
+	g.printf("\n// MarshalJSON 实现 json.Marshaler，输出与 encoding/json 相同\n")
+	g.printf("func (v *%s) MarshalJSON() ([]byte, error) {\n", s.Name)
+	g.printf("return v.AppendJSON(make([]byte, 0, %d))\n}\n", g.sizeHint(s))
+	g.printf("\n// AppendJSON 把 v 编码为 JSON 对象追加到 dst\n")
+	g.printf("func (v *%s) AppendJSON(dst []byte) ([]byte, error) {\n", s.Name)
+	if needErr {
+		g.printf("var err error\n")
+	}
+	// 每个字段都以逗号开头，最后把第一个逗号替换为 {，不需要为 omitempty 记录是否是第一个字段
+	g.printf("n := len(dst)\n")
+	for _, f := range s.Fields {
+		key, _ := json.Marshal(f.JSON)
+		x := "v." + f.Name
+		cond := g.nonEmpty(f.Type, x)
+		if f.OmitEmpty && cond != "" {
+			g.printf("if %s {\n", cond)
+		}
+		g.printf("dst = append(dst, %s...)\n", quote(","+string(key)+":"))
+		g.encodeValue(f.Type, x)
+		if f.OmitEmpty && cond != "" {
+			g.printf("}\n")
+		}
+	}
+	g.printf("if len(dst) == n {\ndst = append(dst, '{')\n} else {\ndst[n] = '{'\n}\n")
+	g.printf("return append(dst, '}'), nil\n}\n")
+}
+
+// elemBasic 返回 basic 类型或 basic 元素的数组、切片的元素信息
+func elemBasic(t types.Type) *basicType {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return basicInfo(u)
+	case *types.Array:
+		return elemBasic(u.Elem())
+	case *types.Slice:
+		return elemBasic(u.Elem())
+	}
+	return nil
+}
+
+// nonEmpty 返回 omitempty 判断值非空的条件，结构体永远不为空，返回 ""
+func (g *generator) nonEmpty(t types.Type, x string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return x
+		case u.Info()&types.IsString != 0:
+			return "len(" + x + ") != 0"
+		default:
+			return x + " != 0"
+		}
+	case *types.Slice, *types.Map, *types.Array:
+		return "len(" + x + ") != 0"
+	case *types.Pointer, *types.Interface, *types.Chan, *types.Signature:
+		return x + " != nil"
+	}
+	return ""
+}
+
+func (g *generator) encodeBasic(t types.Type, x string) {
+	info := basicInfo(t.Underlying().(*types.Basic))
+	arg := x
+	if g.typeName(t) != info.conv {
+		arg = info.conv + "(" + x + ")"
+	}
+	switch info.read {
+	case "Float":
+		g.printf("if dst, err = hpjson.AppendFloat(dst, %s, %d); err != nil {\nreturn nil, err\n}\n", arg, info.bits)
+		return
+	case "String":
+	default:
+		g.imports["strconv"] = "strconv"
+	}
+	g.printf("dst = "+info.apply+"\n", arg)
+}
+
+func (g *generator) encodeValue(t types.Type, x string) {
+	switch g.kindOf(t) {
+	case basic:
+		g.encodeBasic(t, x)
+	case bytesKind:
+		if g.typeName(t) == "[]byte" {
+			g.printf("dst = hpjson.AppendBytes(dst, %s)\n", x)
+		} else {
+			g.printf("dst = hpjson.AppendBytes(dst, []byte(%s))\n", x)
+		}
+	case arrayKind, sliceKind:
+		var elem types.Type
+		if a, ok := t.Underlying().(*types.Array); ok {
+			elem = a.Elem()
+		} else {
+			elem = t.Underlying().(*types.Slice).Elem()
+			g.printf("if %s == nil {\ndst = append(dst, \"null\"...)\n} else {\n", x)
+		}
+		g.printf("dst = append(dst, '[')\n")
+		g.printf("for i, e := range %s {\nif i > 0 {\ndst = append(dst, ',')\n}\n", x)
+		g.encodeBasic(elem, "e")
+		g.printf("}\ndst = append(dst, ']')\n")
+		if _, ok := t.Underlying().(*types.Slice); ok {
+			g.printf("}\n")
+		}
+	case structKind:
+		g.printf("if dst, err = %s.AppendJSON(dst); err != nil {\nreturn nil, err\n}\n", x)
+	default:
+		// 取地址以便 encoding/json 使用指针接收者的方法，与编码整个结构体时字段可寻址的行为一致
+		g.imports["encoding/json"] = "json"
+		g.printf("{\nb, err := json.Marshal(&%s)\nif err != nil {\nreturn nil, err\n}\ndst = append(dst, b...)\n}\n", x)
+	}
+}
+
+func (g *generator) decoder(s *Struct) {
+	g.printf("\n// UnmarshalJSON 实现 json.Unmarshaler，接受的输入和解码结果与 encoding/json 相同\n")
+	g.printf("func (v *%s) UnmarshalJSON(data []byte) error {\n", s.Name)
+	g.printf("var l hpjson.Lexer\nl.Reset(data)\nv.DecodeJSON(&l)\nreturn l.End()\n}\n")
+	g.printf("\n// DecodeJSON 从 l 读取一个 JSON 对象解码到 v，错误记录在 l 中\n")
+	g.printf("func (v *%s) DecodeJSON(l *hpjson.Lexer) {\n", s.Name)
+	g.printf("if l.Null() {\nreturn\n}\nl.BeginObject()\nfor l.NextKey() {\n")
+	// 先精确匹配，再按 encoding/json 的折叠规则大小写不敏感地匹配，折叠后重名时第一个字段优先
+	g.printf("f := -1\nswitch string(l.Key()) {\n")
+	for i, f := range s.Fields {
+		g.printf("case %s:\nf = %d\n", strconv.Quote(f.JSON), i)
+	}
+	g.printf("default:\nswitch string(l.FoldedKey()) {\n")
+	seen := make(map[string]bool)
+	for i, f := range s.Fields {
+		folded := hpjson.FoldName(f.JSON)
+		if seen[folded] {
+			continue
+		}
+		seen[folded] = true
+		g.printf("case %s:\nf = %d\n", strconv.Quote(folded), i)
+	}
+	g.printf("}\n}\nswitch f {\n")
+	for i, f := range s.Fields {
+		g.printf("case %d:\n", i)
+		g.decodeValue(f.Type, "v."+f.Name)
+	}
+	g.printf("default:\nl.Skip()\n}\n}\n}\n")
+}
+
+// decodeBasic 读取一个 basic 类型的值，成功时赋值给 x
+func (g *generator) decodeBasic(t types.Type, x string) {
+	info := basicInfo(t.Underlying().(*types.Basic))
+	name := g.typeName(t)
+	var call string
+	switch info.read {
+	case "Bool", "String":
+		call = fmt.Sprintf("l.%s(%q)", info.read, name)
+	default:
+		call = fmt.Sprintf("l.%s(%d, %q)", info.read, info.bits, name)
+	}
+	g.printf("if r, ok := %s; ok {\n%s = %s\n}\n", call, x, g.conv(t, info.conv, "r"))
+}
+
+func (g *generator) decodeValue(t types.Type, x string) {
+	switch g.kindOf(t) {
+	case basic:
+		g.decodeBasic(t, x)
+	case bytesKind:
+		if e := t.Underlying().(*types.Slice).Elem(); types.Identical(e, types.Typ[types.Uint8]) {
+			if g.typeName(t) == "[]byte" {
+				g.printf("l.Bytes(&%s)\n", x)
+			} else {
+				g.printf("l.Bytes((*[]byte)(&%s))\n", x)
+			}
+		} else {
+			g.printf("l.Decode(&%s)\n", x)
+		}
+	case arrayKind:
+		// 多余的元素被忽略，不足的元素被置零
+		elem := t.Underlying().(*types.Array).Elem()
+		g.printf("if !l.Null() {\ni := 0\nfor l.BeginArray(); l.NextElem(); i++ {\nif i < len(%s) {\n", x)
+		g.decodeBasic(elem, x+"[i]")
+		g.printf("} else {\nl.Skip()\n}\n}\n")
+		g.printf("for ; i < len(%s); i++ {\n%s[i] = %s\n}\n}\n", x, x, zero(elem))
+	case sliceKind:
+		// 与 encoding/json 相同：null 置为 nil，空数组得到长度为 0 的非 nil 切片，复用原有的底层数组
+		elem := t.Underlying().(*types.Slice).Elem()
+		g.printf("if l.Null() {\nif l.Err() == nil {\n%s = nil\n}\n} else {\n", x)
+		g.printf("s := %s[:0]\nfor l.BeginArray(); l.NextElem(); {\nvar e %s\n", x, g.typeName(elem))
+		g.decodeBasic(elem, "e")
+		g.printf("s = append(s, e)\n}\nif s == nil {\ns = make(%s, 0)\n}\n%s = s\n}\n", g.typeName(t), x)
+	case structKind:
+		g.printf("%s.DecodeJSON(l)\n", x)
+	default:
+		g.printf("l.Decode(&%s)\n", x)
+	}
+}
+
+// zero 返回 basic 类型零值的字面量
+func zero(t types.Type) string {
+	info := t.Underlying().(*types.Basic).Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return "false"
+	case info&types.IsString != 0:
+		return `""`
+	}
+	return "0"
+}
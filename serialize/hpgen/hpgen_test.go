@@ -0,0 +1,50 @@
+package hpgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 生成器的行为由 internal/kitchen 中的模糊测试与 encoding/json 对比验证，
+// 这里只检查仓库中提交的生成文件与当前的生成器保持一致
+func TestGeneratedUpToDate(t *testing.T) {
+	for _, dir := range []string{"internal/kitchen", "../../concurrency", "../../datastruct"} {
+		p, err := Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := p.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) == 0 {
+			t.Errorf("%s: no annotated structs", dir)
+		}
+		for name, src := range files {
+			path := filepath.Join(p.Dir, name)
+			old, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(old, src) {
+				t.Errorf("%s is out of date, run go generate", path)
+			}
+		}
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	for in, want := range map[string]string{
+		"student.go":       "student_hpgen.go",
+		"syncpool_test.go": "syncpool_hpgen_test.go",
+	} {
+		if got := OutputFile(in); got != want {
+			t.Errorf("OutputFile(%q) = %q, want %q", in, got, want)
+		}
+		if !IsGenerated(want) || IsGenerated(in) {
+			t.Errorf("IsGenerated(%q) or IsGenerated(%q) is wrong", in, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package kitchen 包含覆盖 hpgen 各种字段类型的结构体，用于和 encoding/json 对比测试生成的代码。
+package kitchen
+
+//go:generate go run highPerformance/cmd/hpgen .
+
+import (
+	"time"
+)
+
+type Level int8
+
+type Name string
+
+type Tags []string
+
+//hpgen:json
+type Sink struct {
+	S       string
+	B       bool
+	I       int
+	I8      int8
+	I16     int16 `json:"i16"`
+	I32     int32 `json:",omitempty"`
+	I64     int64 `json:"i64,omitempty"`
+	U       uint
+	U8      uint8
+	U16     uint16
+	U32     uint32
+	U64     uint64
+	F32     float32
+	F64     float64 `json:"f64,omitempty"`
+	Level   Level
+	Name    Name `json:"name,omitempty"`
+	Bytes   []byte
+	Arr     [3]uint8
+	Strs    [2]string
+	Ints    []int
+	Tags    Tags `json:"tags,omitempty"`
+	Month   time.Month
+	Time    time.Time
+	Ptr     *int           `json:",omitempty"`
+	Map     map[string]int `json:"map,omitempty"`
+	Any     interface{}
+	Inner   Inner
+	Skip    string `json:"-"`
+	Dash    string `json:"-,"`
+	Weird   string `json:"a<b>&"`
+	Unicode string `json:"ünicode"`
+	hidden  int
+	X       int
+	Y       int `json:"X"`
+}
+
+//hpgen:json
+type Inner struct {
+	A int
+	B []float64 `json:"b"`
+}
@@ -0,0 +1,581 @@
+// Code generated by hpgen. DO NOT EDIT.
+
+package kitchen
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"highPerformance/serialize/hpjson"
+)
+
+// MarshalJSON 实现 json.Marshaler，输出与 encoding/json 相同
+func (v *Sink) MarshalJSON() ([]byte, error) {
+	return v.AppendJSON(make([]byte, 0, 699))
+}
+
+// AppendJSON 把 v 编码为 JSON 对象追加到 dst
+func (v *Sink) AppendJSON(dst []byte) ([]byte, error) {
+	var err error
+	n := len(dst)
+	dst = append(dst, `,"S":`...)
+	dst = hpjson.AppendString(dst, v.S)
+	dst = append(dst, `,"B":`...)
+	dst = strconv.AppendBool(dst, v.B)
+	dst = append(dst, `,"I":`...)
+	dst = strconv.AppendInt(dst, int64(v.I), 10)
+	dst = append(dst, `,"I8":`...)
+	dst = strconv.AppendInt(dst, int64(v.I8), 10)
+	dst = append(dst, `,"i16":`...)
+	dst = strconv.AppendInt(dst, int64(v.I16), 10)
+	if v.I32 != 0 {
+		dst = append(dst, `,"I32":`...)
+		dst = strconv.AppendInt(dst, int64(v.I32), 10)
+	}
+	if v.I64 != 0 {
+		dst = append(dst, `,"i64":`...)
+		dst = strconv.AppendInt(dst, v.I64, 10)
+	}
+	dst = append(dst, `,"U":`...)
+	dst = strconv.AppendUint(dst, uint64(v.U), 10)
+	dst = append(dst, `,"U8":`...)
+	dst = strconv.AppendUint(dst, uint64(v.U8), 10)
+	dst = append(dst, `,"U16":`...)
+	dst = strconv.AppendUint(dst, uint64(v.U16), 10)
+	dst = append(dst, `,"U32":`...)
+	dst = strconv.AppendUint(dst, uint64(v.U32), 10)
+	dst = append(dst, `,"U64":`...)
+	dst = strconv.AppendUint(dst, v.U64, 10)
+	dst = append(dst, `,"F32":`...)
+	if dst, err = hpjson.AppendFloat(dst, float64(v.F32), 32); err != nil {
+		return nil, err
+	}
+	if v.F64 != 0 {
+		dst = append(dst, `,"f64":`...)
+		if dst, err = hpjson.AppendFloat(dst, v.F64, 64); err != nil {
+			return nil, err
+		}
+	}
+	dst = append(dst, `,"Level":`...)
+	dst = strconv.AppendInt(dst, int64(v.Level), 10)
+	if len(v.Name) != 0 {
+		dst = append(dst, `,"name":`...)
+		dst = hpjson.AppendString(dst, string(v.Name))
+	}
+	dst = append(dst, `,"Bytes":`...)
+	dst = hpjson.AppendBytes(dst, v.Bytes)
+	dst = append(dst, `,"Arr":`...)
+	dst = append(dst, '[')
+	for i, e := range v.Arr {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = strconv.AppendUint(dst, uint64(e), 10)
+	}
+	dst = append(dst, ']')
+	dst = append(dst, `,"Strs":`...)
+	dst = append(dst, '[')
+	for i, e := range v.Strs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = hpjson.AppendString(dst, e)
+	}
+	dst = append(dst, ']')
+	dst = append(dst, `,"Ints":`...)
+	if v.Ints == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i, e := range v.Ints {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendInt(dst, int64(e), 10)
+		}
+		dst = append(dst, ']')
+	}
+	if len(v.Tags) != 0 {
+		dst = append(dst, `,"tags":`...)
+		if v.Tags == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '[')
+			for i, e := range v.Tags {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				dst = hpjson.AppendString(dst, e)
+			}
+			dst = append(dst, ']')
+		}
+	}
+	dst = append(dst, `,"Month":`...)
+	dst = strconv.AppendInt(dst, int64(v.Month), 10)
+	dst = append(dst, `,"Time":`...)
+	{
+		b, err := json.Marshal(&v.Time)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, b...)
+	}
+	if v.Ptr != nil {
+		dst = append(dst, `,"Ptr":`...)
+		{
+			b, err := json.Marshal(&v.Ptr)
+			if err != nil {
+				return nil, err
+			}
+			dst = append(dst, b...)
+		}
+	}
+	if len(v.Map) != 0 {
+		dst = append(dst, `,"map":`...)
+		{
+			b, err := json.Marshal(&v.Map)
+			if err != nil {
+				return nil, err
+			}
+			dst = append(dst, b...)
+		}
+	}
+	dst = append(dst, `,"Any":`...)
+	{
+		b, err := json.Marshal(&v.Any)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, b...)
+	}
+	dst = append(dst, `,"Inner":`...)
+	if dst, err = v.Inner.AppendJSON(dst); err != nil {
+		return nil, err
+	}
+	dst = append(dst, `,"-":`...)
+	dst = hpjson.AppendString(dst, v.Dash)
+	dst = append(dst, `,"a\u003cb\u003e\u0026":`...)
+	dst = hpjson.AppendString(dst, v.Weird)
+	dst = append(dst, `,"ünicode":`...)
+	dst = hpjson.AppendString(dst, v.Unicode)
+	dst = append(dst, `,"X":`...)
+	dst = strconv.AppendInt(dst, int64(v.Y), 10)
+	if len(dst) == n {
+		dst = append(dst, '{')
+	} else {
+		dst[n] = '{'
+	}
+	return append(dst, '}'), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，接受的输入和解码结果与 encoding/json 相同
+func (v *Sink) UnmarshalJSON(data []byte) error {
+	var l hpjson.Lexer
+	l.Reset(data)
+	v.DecodeJSON(&l)
+	return l.End()
+}
+
+// DecodeJSON 从 l 读取一个 JSON 对象解码到 v，错误记录在 l 中
+func (v *Sink) DecodeJSON(l *hpjson.Lexer) {
+	if l.Null() {
+		return
+	}
+	l.BeginObject()
+	for l.NextKey() {
+		f := -1
+		switch string(l.Key()) {
+		case "S":
+			f = 0
+		case "B":
+			f = 1
+		case "I":
+			f = 2
+		case "I8":
+			f = 3
+		case "i16":
+			f = 4
+		case "I32":
+			f = 5
+		case "i64":
+			f = 6
+		case "U":
+			f = 7
+		case "U8":
+			f = 8
+		case "U16":
+			f = 9
+		case "U32":
+			f = 10
+		case "U64":
+			f = 11
+		case "F32":
+			f = 12
+		case "f64":
+			f = 13
+		case "Level":
+			f = 14
+		case "name":
+			f = 15
+		case "Bytes":
+			f = 16
+		case "Arr":
+			f = 17
+		case "Strs":
+			f = 18
+		case "Ints":
+			f = 19
+		case "tags":
+			f = 20
+		case "Month":
+			f = 21
+		case "Time":
+			f = 22
+		case "Ptr":
+			f = 23
+		case "map":
+			f = 24
+		case "Any":
+			f = 25
+		case "Inner":
+			f = 26
+		case "-":
+			f = 27
+		case "a<b>&":
+			f = 28
+		case "ünicode":
+			f = 29
+		case "X":
+			f = 30
+		default:
+			switch string(l.FoldedKey()) {
+			case "S":
+				f = 0
+			case "B":
+				f = 1
+			case "I":
+				f = 2
+			case "I8":
+				f = 3
+			case "I16":
+				f = 4
+			case "I32":
+				f = 5
+			case "I64":
+				f = 6
+			case "U":
+				f = 7
+			case "U8":
+				f = 8
+			case "U16":
+				f = 9
+			case "U32":
+				f = 10
+			case "U64":
+				f = 11
+			case "F32":
+				f = 12
+			case "F64":
+				f = 13
+			case "LEVEL":
+				f = 14
+			case "NAME":
+				f = 15
+			case "BYTES":
+				f = 16
+			case "ARR":
+				f = 17
+			case "STRS":
+				f = 18
+			case "INTS":
+				f = 19
+			case "TAGS":
+				f = 20
+			case "MONTH":
+				f = 21
+			case "TIME":
+				f = 22
+			case "PTR":
+				f = 23
+			case "MAP":
+				f = 24
+			case "ANY":
+				f = 25
+			case "INNER":
+				f = 26
+			case "-":
+				f = 27
+			case "A<B>&":
+				f = 28
+			case "ÜNICODE":
+				f = 29
+			case "X":
+				f = 30
+			}
+		}
+		switch f {
+		case 0:
+			if r, ok := l.String("string"); ok {
+				v.S = r
+			}
+		case 1:
+			if r, ok := l.Bool("bool"); ok {
+				v.B = r
+			}
+		case 2:
+			if r, ok := l.Int(0, "int"); ok {
+				v.I = int(r)
+			}
+		case 3:
+			if r, ok := l.Int(8, "int8"); ok {
+				v.I8 = int8(r)
+			}
+		case 4:
+			if r, ok := l.Int(16, "int16"); ok {
+				v.I16 = int16(r)
+			}
+		case 5:
+			if r, ok := l.Int(32, "int32"); ok {
+				v.I32 = int32(r)
+			}
+		case 6:
+			if r, ok := l.Int(64, "int64"); ok {
+				v.I64 = r
+			}
+		case 7:
+			if r, ok := l.Uint(0, "uint"); ok {
+				v.U = uint(r)
+			}
+		case 8:
+			if r, ok := l.Uint(8, "uint8"); ok {
+				v.U8 = uint8(r)
+			}
+		case 9:
+			if r, ok := l.Uint(16, "uint16"); ok {
+				v.U16 = uint16(r)
+			}
+		case 10:
+			if r, ok := l.Uint(32, "uint32"); ok {
+				v.U32 = uint32(r)
+			}
+		case 11:
+			if r, ok := l.Uint(64, "uint64"); ok {
+				v.U64 = r
+			}
+		case 12:
+			if r, ok := l.Float(32, "float32"); ok {
+				v.F32 = float32(r)
+			}
+		case 13:
+			if r, ok := l.Float(64, "float64"); ok {
+				v.F64 = r
+			}
+		case 14:
+			if r, ok := l.Int(8, "Level"); ok {
+				v.Level = Level(r)
+			}
+		case 15:
+			if r, ok := l.String("Name"); ok {
+				v.Name = Name(r)
+			}
+		case 16:
+			l.Bytes(&v.Bytes)
+		case 17:
+			if !l.Null() {
+				i := 0
+				for l.BeginArray(); l.NextElem(); i++ {
+					if i < len(v.Arr) {
+						if r, ok := l.Uint(8, "uint8"); ok {
+							v.Arr[i] = uint8(r)
+						}
+					} else {
+						l.Skip()
+					}
+				}
+				for ; i < len(v.Arr); i++ {
+					v.Arr[i] = 0
+				}
+			}
+		case 18:
+			if !l.Null() {
+				i := 0
+				for l.BeginArray(); l.NextElem(); i++ {
+					if i < len(v.Strs) {
+						if r, ok := l.String("string"); ok {
+							v.Strs[i] = r
+						}
+					} else {
+						l.Skip()
+					}
+				}
+				for ; i < len(v.Strs); i++ {
+					v.Strs[i] = ""
+				}
+			}
+		case 19:
+			if l.Null() {
+				if l.Err() == nil {
+					v.Ints = nil
+				}
+			} else {
+				s := v.Ints[:0]
+				for l.BeginArray(); l.NextElem(); {
+					var e int
+					if r, ok := l.Int(0, "int"); ok {
+						e = int(r)
+					}
+					s = append(s, e)
+				}
+				if s == nil {
+					s = make([]int, 0)
+				}
+				v.Ints = s
+			}
+		case 20:
+			if l.Null() {
+				if l.Err() == nil {
+					v.Tags = nil
+				}
+			} else {
+				s := v.Tags[:0]
+				for l.BeginArray(); l.NextElem(); {
+					var e string
+					if r, ok := l.String("string"); ok {
+						e = r
+					}
+					s = append(s, e)
+				}
+				if s == nil {
+					s = make(Tags, 0)
+				}
+				v.Tags = s
+			}
+		case 21:
+			if r, ok := l.Int(0, "time.Month"); ok {
+				v.Month = time.Month(r)
+			}
+		case 22:
+			l.Decode(&v.Time)
+		case 23:
+			l.Decode(&v.Ptr)
+		case 24:
+			l.Decode(&v.Map)
+		case 25:
+			l.Decode(&v.Any)
+		case 26:
+			v.Inner.DecodeJSON(l)
+		case 27:
+			if r, ok := l.String("string"); ok {
+				v.Dash = r
+			}
+		case 28:
+			if r, ok := l.String("string"); ok {
+				v.Weird = r
+			}
+		case 29:
+			if r, ok := l.String("string"); ok {
+				v.Unicode = r
+			}
+		case 30:
+			if r, ok := l.Int(0, "int"); ok {
+				v.Y = int(r)
+			}
+		default:
+			l.Skip()
+		}
+	}
+}
+
+// MarshalJSON 实现 json.Marshaler，输出与 encoding/json 相同
+func (v *Inner) MarshalJSON() ([]byte, error) {
+	return v.AppendJSON(make([]byte, 0, 44))
+}
+
+// AppendJSON 把 v 编码为 JSON 对象追加到 dst
+func (v *Inner) AppendJSON(dst []byte) ([]byte, error) {
+	var err error
+	n := len(dst)
+	dst = append(dst, `,"A":`...)
+	dst = strconv.AppendInt(dst, int64(v.A), 10)
+	dst = append(dst, `,"b":`...)
+	if v.B == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i, e := range v.B {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, err = hpjson.AppendFloat(dst, e, 64); err != nil {
+				return nil, err
+			}
+		}
+		dst = append(dst, ']')
+	}
+	if len(dst) == n {
+		dst = append(dst, '{')
+	} else {
+		dst[n] = '{'
+	}
+	return append(dst, '}'), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，接受的输入和解码结果与 encoding/json 相同
+func (v *Inner) UnmarshalJSON(data []byte) error {
+	var l hpjson.Lexer
+	l.Reset(data)
+	v.DecodeJSON(&l)
+	return l.End()
+}
+
+// DecodeJSON 从 l 读取一个 JSON 对象解码到 v，错误记录在 l 中
+func (v *Inner) DecodeJSON(l *hpjson.Lexer) {
+	if l.Null() {
+		return
+	}
+	l.BeginObject()
+	for l.NextKey() {
+		f := -1
+		switch string(l.Key()) {
+		case "A":
+			f = 0
+		case "b":
+			f = 1
+		default:
+			switch string(l.FoldedKey()) {
+			case "A":
+				f = 0
+			case "B":
+				f = 1
+			}
+		}
+		switch f {
+		case 0:
+			if r, ok := l.Int(0, "int"); ok {
+				v.A = int(r)
+			}
+		case 1:
+			if l.Null() {
+				if l.Err() == nil {
+					v.B = nil
+				}
+			} else {
+				s := v.B[:0]
+				for l.BeginArray(); l.NextElem(); {
+					var e float64
+					if r, ok := l.Float(64, "float64"); ok {
+						e = r
+					}
+					s = append(s, e)
+				}
+				if s == nil {
+					s = make([]float64, 0)
+				}
+				v.B = s
+			}
+		default:
+			l.Skip()
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package kitchen
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// plainSink 和 plainInner 与 Sink、Inner 的字段和标签完全相同，但没有生成的方法，
+// encoding/json 处理它们时使用反射，作为对比的基准。
+// plainSink 的 Inner 字段依然是 Inner 类型，会调用生成的方法，Inner 本身由 plainInner 单独对比。
+type (
+	plainSink  Sink
+	plainInner Inner
+)
+
+var seeds = []string{
+	`{}`,
+	`null`,
+	`{"S":"hello","B":true,"I":-1,"I8":127,"i16":-32768,"I32":5,"i64":-9,"U":1,"U8":255,"U16":65535,"U32":1,"U64":18446744073709551615}`,
+	`{"F32":1.5,"f64":-2e-7,"Level":3,"name":"n","Bytes":"AQID","Arr":[1,2],"Strs":["a","b","c"],"Ints":[],"tags":null}`,
+	`{"Month":12,"Time":"2024-01-02T03:04:05Z","Ptr":7,"map":{"a":1},"Any":[1,"x",null],"Inner":{"A":1,"b":[0.5]}}`,
+	`{"-":"dash","a<b>&":"weird","ünicode":"ü","X":1,"Y":2,"Skip":"no","hidden":1,"unknown":{"deep":[true]}}`,
+	`{"s":"case","NAME":"fold","INNER":{"a":2},"ÜNICODE":"x"}`,
+	`{"I8":128}`, `{"U":-1}`, `{"I":1.5}`, `{"S":1}`, `{"Arr":[1,2,3,4]}`, `{"Bytes":[1,2]}`, `{"Bytes":"!"}`,
+	`{"S":"é😀\ud800"}`, `{"S":"a",}`, `[]`, `{"S":"a"} x`, `{"Ints":[1,null,3]}`,
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var got, want Sink
+		gerr := got.UnmarshalJSON(data)
+		werr := json.Unmarshal(data, (*plainSink)(&want))
+		if (gerr == nil) != (werr == nil) {
+			t.Fatalf("Unmarshal(%s): error %v, encoding/json error %v", data, gerr, werr)
+		}
+		if werr == nil && !reflect.DeepEqual(got, want) {
+			t.Fatalf("Unmarshal(%s):\n got %+v\nwant %+v", data, got, want)
+		}
+
+		var gi, wi Inner
+		gerr = gi.UnmarshalJSON(data)
+		werr = json.Unmarshal(data, (*plainInner)(&wi))
+		if (gerr == nil) != (werr == nil) || werr == nil && !reflect.DeepEqual(gi, wi) {
+			t.Fatalf("Inner.Unmarshal(%s): %+v, %v, want %+v, %v", data, gi, gerr, wi, werr)
+		}
+	})
+}
+
+func FuzzMarshal(f *testing.F) {
+	f.Add("hello", int64(1), uint64(2), 1.5, []byte("bytes"), true)
+	f.Add("<&> \xff", int64(math.MinInt64), uint64(math.MaxUint64), math.Inf(1), []byte(nil), false)
+	f.Add("", int64(0), uint64(0), 0.0, []byte{}, false)
+	f.Fuzz(func(t *testing.T, s string, i int64, u uint64, fl float64, b []byte, flag bool) {
+		p := int(i)
+		v := Sink{
+			S: s, B: flag, I: int(i), I8: int8(i), I16: int16(i), I32: int32(i), I64: i,
+			U: uint(u), U8: uint8(u), U16: uint16(u), U32: uint32(u), U64: u,
+			F32: float32(fl), F64: fl, Level: Level(i), Name: Name(s), Bytes: b,
+			Arr: [3]uint8{uint8(u), 1, 2}, Strs: [2]string{s, "x"}, Tags: Tags{s},
+			Month: time.Month(i), Time: time.Unix(i%1e10, 0).UTC(), Any: s,
+			Inner: Inner{A: int(i), B: []float64{fl}}, Dash: s, Weird: s, Unicode: s, Y: int(i),
+		}
+		if flag {
+			v.Ptr, v.Map, v.Ints = &p, map[string]int{s: p}, []int{p, 0}
+		}
+		got, gerr := v.MarshalJSON()
+		want, werr := json.Marshal((*plainSink)(&v))
+		if (gerr == nil) != (werr == nil) {
+			t.Fatalf("Marshal(%+v): error %v, encoding/json error %v", v, gerr, werr)
+		}
+		if werr != nil {
+			return
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Marshal:\n got %s\nwant %s", got, want)
+		}
+		// 往返：解码自己的输出后再编码，结果不变
+		var back Sink
+		if err := back.UnmarshalJSON(got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", got, err)
+		}
+		again, _ := back.MarshalJSON()
+		if !bytes.Equal(again, got) {
+			t.Fatalf("round trip:\n got %s\nwant %s", again, got)
+		}
+	})
+}
+
+func TestUnmarshalAllocs(t *testing.T) {
+	data := []byte(`{"A":1,"b":[0.5,1.5]}`)
+	var v Inner
+	allocs := testing.AllocsPerRun(100, func() {
+		v.B = v.B[:0]
+		v.UnmarshalJSON(data)
+	})
+	if allocs > 0 {
+		t.Errorf("Inner.UnmarshalJSON allocates %v times", allocs)
+	}
+}
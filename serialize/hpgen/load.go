@@ -0,0 +1,273 @@
+// Package hpgen 为带有 //hpgen:json 注释的结构体生成不使用反射的 MarshalJSON 和 UnmarshalJSON。
+// 与 easyjson 类似，生成的代码只依赖 highPerformance/serialize/hpjson，
+// 字段名、json 标签、omitempty、大小写不敏感的键匹配等规则与 encoding/json 一致。
+//
+// 结构体定义在 _test.go 中时，生成的代码也写入 _test.go 文件。
+package hpgen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Annotation 是标记需要生成代码的结构体的注释
+const Annotation = "//hpgen:json"
+
+// Package 是加载后的包
+type Package struct {
+	Dir     string
+	Name    string
+	Path    string
+	Structs []*Struct
+	types   *types.Package
+}
+
+// Struct 是带有注释的结构体
+type Struct struct {
+	Name   string
+	File   string // 定义所在的文件名，不含目录
+	Fields []*Field
+	Named  *types.Named
+}
+
+// Field 是参与编解码的字段
+type Field struct {
+	Name      string // Go 字段名
+	JSON      string // JSON 键
+	OmitEmpty bool
+	Type      types.Type
+}
+
+// Load 解析并类型检查 dir 中的包（包括同一包名的 _test.go 文件），返回带有注释的结构体。
+// 依赖包的类型信息来自 go list -export 生成的导出数据。
+// 已生成的文件不参与解析；其他代码引用生成的方法导致的类型错误会被忽略，不影响结构体字段的类型。
+func Load(dir string) (*Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	path, exports, err := listExports(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !IsGenerated(fi.Name())
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	var name string
+	for n, p := range pkgs {
+		if strings.HasSuffix(n, "_test") && len(pkgs) > 1 {
+			continue
+		}
+		name = n
+		// 按文件名排序，保证生成结果稳定
+		fnames := make([]string, 0, len(p.Files))
+		for fname := range p.Files {
+			fnames = append(fnames, fname)
+		}
+		sort.Strings(fnames)
+		for _, fname := range fnames {
+			files = append(files, p.Files[fname])
+		}
+	}
+	if files == nil {
+		return nil, fmt.Errorf("hpgen: no Go files in %s", dir)
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+			file, ok := exports[path]
+			if !ok {
+				return nil, fmt.Errorf("no export data for %s", path)
+			}
+			return os.Open(file)
+		}),
+		Error: func(error) {},
+	}
+	tpkg, _ := conf.Check(path, fset, files, nil)
+	p := &Package{Dir: dir, Name: name, Path: path, types: tpkg}
+	for _, file := range files {
+		fname := filepath.Base(fset.Position(file.Pos()).Filename)
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if !annotated(ts.Doc) && !(len(gd.Specs) == 1 && annotated(gd.Doc)) {
+					continue
+				}
+				s, err := p.newStruct(ts.Name.Name, fname)
+				if err != nil {
+					return nil, err
+				}
+				p.Structs = append(p.Structs, s)
+			}
+		}
+	}
+	return p, nil
+}
+
+// IsGenerated 报告文件是否是 hpgen 生成的
+func IsGenerated(name string) bool {
+	return strings.HasSuffix(name, "_hpgen.go") || strings.HasSuffix(name, "_hpgen_test.go")
+}
+
+// OutputFile 返回 file 中的结构体生成代码的文件名
+func OutputFile(file string) string {
+	if strings.HasSuffix(file, "_test.go") {
+		return strings.TrimSuffix(file, "_test.go") + "_hpgen_test.go"
+	}
+	return strings.TrimSuffix(file, ".go") + "_hpgen.go"
+}
+
+func annotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == Annotation {
+			return true
+		}
+	}
+	return false
+}
+
+// listExports 返回 dir 中包的导入路径，以及包括测试依赖在内的所有依赖包的导出数据文件
+func listExports(dir string) (string, map[string]string, error) {
+	cmd := exec.Command("go", "list", "-e", "-export", "-deps", "-test", "-f", "{{.ImportPath}}\t{{.Export}}", ".")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("go list: %v\n%s", err, stderr.String())
+	}
+	exports := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		path, export, _ := strings.Cut(sc.Text(), "\t")
+		// 测试变体形如 "p [q.test]"，不带后缀的版本优先
+		if i := strings.Index(path, " ["); i >= 0 {
+			path = path[:i]
+			if _, ok := exports[path]; ok {
+				continue
+			}
+		}
+		if export != "" {
+			exports[path] = export
+		}
+	}
+	self, err := exec.Command("go", "list", "-f", "{{.ImportPath}}", dir).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("go list %s: %v", dir, err)
+	}
+	return strings.TrimSpace(string(self)), exports, nil
+}
+
+func (p *Package) newStruct(name, file string) (*Struct, error) {
+	obj, ok := p.types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("hpgen: type %s not found", name)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("hpgen: %s is not a named type", name)
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("hpgen: %s is not a struct", name)
+	}
+	s := &Struct{Name: name, File: file, Named: named}
+	type candidate struct {
+		f      *Field
+		tagged bool
+	}
+	var cands []candidate
+	count := make(map[string]int)
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		if v.Embedded() {
+			if !v.Exported() {
+				if _, ok := v.Type().Underlying().(*types.Struct); !ok {
+					continue // 与 encoding/json 相同，忽略非导出的非结构体嵌入字段
+				}
+			}
+			return nil, fmt.Errorf("hpgen: %s.%s: embedded fields are not supported", name, v.Name())
+		}
+		if !v.Exported() {
+			continue
+		}
+		jsonName, opts, _ := strings.Cut(tag, ",")
+		tagged := isValidTag(jsonName)
+		if !tagged {
+			jsonName = v.Name()
+		}
+		f := &Field{Name: v.Name(), JSON: jsonName, Type: v.Type()}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				f.OmitEmpty = true
+			case "string":
+				return nil, fmt.Errorf("hpgen: %s.%s: the ,string option is not supported", name, v.Name())
+			}
+		}
+		cands = append(cands, candidate{f, tagged})
+		count[jsonName]++
+	}
+	// 同名字段中只有一个带标签时保留它，否则全部忽略，与 encoding/json 的规则相同
+	for _, c := range cands {
+		if count[c.f.JSON] == 1 {
+			s.Fields = append(s.Fields, c.f)
+			continue
+		}
+		tagged := 0
+		for _, o := range cands {
+			if o.f.JSON == c.f.JSON && o.tagged {
+				tagged++
+			}
+		}
+		if tagged == 1 && c.tagged {
+			s.Fields = append(s.Fields, c.f)
+		}
+	}
+	return s, nil
+}
+
+// isValidTag 与 encoding/json 相同
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// 允许的标点
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
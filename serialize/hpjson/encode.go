@@ -0,0 +1,123 @@
+// Package hpjson 是 hpgen 生成的 JSON 编解码代码所依赖的运行时。
+// reflect_test.go 提到标准库 encoding/json 依靠反射实现，hpgen 在编译前根据结构体定义生成专门的代码，
+// 运行时只剩下本包中与类型无关的部分：字符串转义、数字格式化和一个不分配内存的词法分析器。
+//
+// 编码结果与 encoding/json 逐字节相同（包括 HTML 字符转义和浮点数格式），
+// 解码接受与 encoding/json 相同的输入，并得到相同的结果。
+package hpjson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+const hex = "0123456789abcdef"
+
+// htmlSafe[b] 表示 ASCII 字符 b 在字符串中可以原样输出，
+// 与 encoding/json 相同，< > & 被转义为 \u003c 等，避免在 HTML 中被误解析
+var htmlSafe = func() (set [utf8.RuneSelf]bool) {
+	for b := ' '; b < utf8.RuneSelf; b++ {
+		switch b {
+		case '"', '\\', '<', '>', '&':
+		default:
+			set[b] = true
+		}
+	}
+	return
+}()
+
+// AppendString 把 s 编码为 JSON 字符串追加到 dst，
+// 非法的 UTF-8 被替换为 U+FFFD，U+2028 和 U+2029 被转义
+func AppendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if htmlSafe[b] {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// AppendFloat 按 encoding/json 的规则编码浮点数：指数小于 -6 或不小于 21 时使用科学计数法，
+// NaN 和无穷大无法用 JSON 表示，返回错误
+func AppendFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// e-09 改写为 e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst, nil
+}
+
+// AppendBytes 把 b 编码为 base64 字符串，nil 编码为 null
+func AppendBytes(dst []byte, b []byte) []byte {
+	if b == nil {
+		return append(dst, "null"...)
+	}
+	n := base64.StdEncoding.EncodedLen(len(b))
+	dst = append(dst, '"')
+	if cap(dst)-len(dst) < n {
+		dst = append(make([]byte, 0, len(dst)+n+1), dst...)
+	}
+	base64.StdEncoding.Encode(dst[len(dst):len(dst)+n], b)
+	dst = dst[:len(dst)+n]
+	return append(dst, '"')
+}
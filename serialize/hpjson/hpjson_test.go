@@ -0,0 +1,142 @@
+package hpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+var strings_ = []string{
+	"", "hello", `quote " and \ backslash`, "<script>&</script>", "\b\f\n\r\t\x00\x1f",
+	"中文", "  ", "invalid \xff utf8", "emoji 😀",
+}
+
+func FuzzAppendString(f *testing.F) {
+	for _, s := range strings_ {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		want, _ := json.Marshal(s)
+		if got := AppendString(nil, s); !bytes.Equal(got, want) {
+			t.Fatalf("AppendString(%q) = %s, want %s", s, got, want)
+		}
+		// 反过来解码也应当与 encoding/json 一致
+		var l Lexer
+		l.Reset(want)
+		var ws string
+		json.Unmarshal(want, &ws)
+		if gs, ok := l.String("string"); !ok || gs != ws || l.End() != nil {
+			t.Fatalf("String(%s) = %q, %v, want %q", want, gs, l.Err(), ws)
+		}
+	})
+}
+
+func FuzzAppendFloat(f *testing.F) {
+	for _, v := range []float64{0, 1, -1.5, 1e-7, 1e20, 1e21, 123456789, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		f.Add(v)
+	}
+	f.Fuzz(func(t *testing.T, v float64) {
+		want, err := json.Marshal(v)
+		got, gerr := AppendFloat(nil, v, 64)
+		if (err == nil) != (gerr == nil) || err == nil && !bytes.Equal(got, want) {
+			t.Fatalf("AppendFloat(%v) = %s, %v, want %s, %v", v, got, gerr, want, err)
+		}
+		want, err = json.Marshal(float32(v))
+		got, gerr = AppendFloat(nil, float64(float32(v)), 32)
+		if (err == nil) != (gerr == nil) || err == nil && !bytes.Equal(got, want) {
+			t.Fatalf("AppendFloat(float32(%v)) = %s, %v, want %s, %v", v, got, gerr, want, err)
+		}
+	})
+}
+
+func TestAppendBytes(t *testing.T) {
+	for _, b := range [][]byte{nil, {}, []byte("hello, world")} {
+		want, _ := json.Marshal(b)
+		if got := AppendBytes(make([]byte, 0, 2), b); !bytes.Equal(got, want) {
+			t.Errorf("AppendBytes(%q) = %s, want %s", b, got, want)
+		}
+	}
+}
+
+// Skip 接受的输入与 json.Valid 完全相同
+func FuzzSkip(f *testing.F) {
+	for _, s := range []string{
+		`{}`, `[]`, `{"a":[1,2,{"b":null}],"c":"é"}`, `-0.1e+10`, `01`, `1.`, `.5`, `"\x01"`,
+		`{"a" 1}`, `[1,]`, `{"a":1,}`, `tru`, `"😀"`, `"\q"`, ` [ true , false ] `,
+	} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var l Lexer
+		l.Reset(data)
+		l.Skip()
+		err := l.End()
+		if valid := json.Valid(data); valid != (err == nil) {
+			t.Fatalf("Skip(%q) error %v, json.Valid = %v", data, err, valid)
+		}
+	})
+}
+
+func TestTypes(t *testing.T) {
+	var l Lexer
+	l.Reset([]byte(`[1, -2, 3.5, true, "x", null, 300, "AQID", [1,2]]`))
+	l.BeginArray()
+	l.NextElem()
+	if n, ok := l.Uint(64, "uint64"); !ok || n != 1 {
+		t.Fatal("uint")
+	}
+	l.NextElem()
+	if n, ok := l.Int(64, "int64"); !ok || n != -2 {
+		t.Fatal("int")
+	}
+	l.NextElem()
+	if f, ok := l.Float(64, "float64"); !ok || f != 3.5 {
+		t.Fatal("float")
+	}
+	l.NextElem()
+	if b, ok := l.Bool("bool"); !ok || !b {
+		t.Fatal("bool")
+	}
+	l.NextElem()
+	if s, ok := l.String("string"); !ok || s != "x" {
+		t.Fatal("string")
+	}
+	l.NextElem()
+	if _, ok := l.Int(64, "int"); ok || l.Err() != nil {
+		t.Fatal("null should not be ok")
+	}
+	l.NextElem()
+	l.Skip()
+	var b []byte
+	l.NextElem()
+	l.Bytes(&b)
+	if string(b) != "\x01\x02\x03" {
+		t.Fatalf("bytes = %q", b)
+	}
+	l.NextElem()
+	l.Bytes(&b)
+	if string(b) != "\x01\x02" {
+		t.Fatalf("bytes = %q", b)
+	}
+	if l.NextElem() || l.End() != nil {
+		t.Fatal(l.Err())
+	}
+
+	// 300 超出 int8 的范围
+	l.Reset([]byte(`300`))
+	if _, ok := l.Int(8, "int8"); ok {
+		t.Fatal("expected overflow")
+	}
+	if _, ok := l.Err().(*TypeError); !ok {
+		t.Fatalf("unexpected error %v", l.Err())
+	}
+}
+
+func TestFoldName(t *testing.T) {
+	for name, want := range map[string]string{"server-name": "SERVER-NAME", "ſ": "S", "K": "K"} {
+		if got := FoldName(name); got != want {
+			t.Errorf("FoldName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,697 @@
+package hpjson
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxDepth 与 encoding/json 相同，限制嵌套层数，避免恶意输入导致栈溢出
+const maxDepth = 10000
+
+// SyntaxError 表示输入不是合法的 JSON
+type SyntaxError struct {
+	Msg    string
+	Offset int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("hpjson: %s at offset %d", e.Msg, e.Offset)
+}
+
+// TypeError 表示 JSON 值的类型与 Go 字段的类型不匹配，或者数值超出范围
+type TypeError struct {
+	Value  string // JSON 值的种类或数字字面量
+	Type   string // Go 类型
+	Offset int
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("hpjson: cannot unmarshal %s into Go value of type %s at offset %d", e.Value, e.Type, e.Offset)
+}
+
+// Lexer 是生成代码使用的 JSON 词法分析器，零值经过 Reset 后可用。
+// 遇到第一个错误后所有方法都不再读取输入，错误由 End 或 Err 返回，生成的代码因此不需要逐个检查错误。
+// 与 encoding/json 不同，类型不匹配也会立即停止，而不是跳过这个字段继续解码其余的字段。
+type Lexer struct {
+	data  []byte
+	pos   int
+	err   error
+	first bool // 刚进入对象或数组，还没有读到第一个元素
+	key   []byte
+	buf   []byte // 反转义字符串使用的缓冲区
+	fold  []byte
+}
+
+// Reset 开始分析 data
+func (l *Lexer) Reset(data []byte) {
+	*l = Lexer{data: data, buf: l.buf[:0], fold: l.fold[:0]}
+}
+
+// Err 返回遇到的第一个错误
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// Fail 记录错误，已有错误时忽略
+func (l *Lexer) Fail(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+func (l *Lexer) syntax(msg string) {
+	l.Fail(&SyntaxError{Msg: msg, Offset: l.pos})
+}
+
+func (l *Lexer) unexpected() {
+	if l.pos >= len(l.data) {
+		l.syntax("unexpected end of JSON input")
+		return
+	}
+	l.syntax(fmt.Sprintf("invalid character %q", l.data[l.pos]))
+}
+
+func (l *Lexer) typeError(value, typ string, offset int) {
+	l.Fail(&TypeError{Value: value, Type: typ, Offset: offset})
+}
+
+func (l *Lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch l.data[l.pos] {
+		case ' ', '\t', '\n', '\r':
+			l.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek 跳过空白并返回下一个字符，输入结束或已出错时返回 0
+func (l *Lexer) peek() byte {
+	if l.err != nil {
+		return 0
+	}
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return 0
+	}
+	return l.data[l.pos]
+}
+
+// End 检查输入中只剩下空白，返回遇到的第一个错误
+func (l *Lexer) End() error {
+	if l.err == nil {
+		l.skipSpace()
+		if l.pos < len(l.data) {
+			l.unexpected()
+		}
+	}
+	return l.err
+}
+
+func (l *Lexer) literal(lit string) bool {
+	if len(l.data)-l.pos >= len(lit) && string(l.data[l.pos:l.pos+len(lit)]) == lit {
+		l.pos += len(lit)
+		return true
+	}
+	// 定位到第一个不匹配的字符
+	for i := 0; i < len(lit) && l.pos < len(l.data) && l.data[l.pos] == lit[i]; i++ {
+		l.pos++
+	}
+	l.unexpected()
+	return false
+}
+
+// Null 在下一个值是 null 时读取它并返回 true。已出错时也返回 true，使调用方跳过对值的处理。
+func (l *Lexer) Null() bool {
+	switch l.peek() {
+	case 0:
+		if l.err == nil {
+			l.unexpected()
+		}
+		return true
+	case 'n':
+		l.literal("null")
+		return true
+	}
+	return false
+}
+
+// BeginObject 读取 {，之后用 NextKey 遍历键
+func (l *Lexer) BeginObject() {
+	if l.peek() != '{' {
+		l.unexpectedValue("object")
+		return
+	}
+	l.pos++
+	l.first = true
+}
+
+// NextKey 读取下一个键和冒号，读到 } 或出错时返回 false。键通过 Key 获取。
+func (l *Lexer) NextKey() bool {
+	c := l.peek()
+	if c == '}' {
+		l.pos++
+		l.first = false
+		return false
+	}
+	if !l.first {
+		if c != ',' {
+			l.unexpected()
+			return false
+		}
+		l.pos++
+		c = l.peek()
+	}
+	l.first = false
+	if c != '"' {
+		l.unexpected()
+		return false
+	}
+	l.key = l.readString()
+	if l.peek() != ':' {
+		l.unexpected()
+		return false
+	}
+	l.pos++
+	return l.err == nil
+}
+
+// Key 返回 NextKey 读到的键（已反转义），在下一次调用 NextKey 之前有效
+func (l *Lexer) Key() []byte {
+	return l.key
+}
+
+// FoldedKey 返回 Key 按 FoldName 折叠后的结果，用于大小写不敏感的匹配
+func (l *Lexer) FoldedKey() []byte {
+	l.fold = AppendFoldedName(l.fold[:0], l.key)
+	return l.fold
+}
+
+// BeginArray 读取 [，之后用 NextElem 遍历元素
+func (l *Lexer) BeginArray() {
+	if l.peek() != '[' {
+		l.unexpectedValue("array")
+		return
+	}
+	l.pos++
+	l.first = true
+}
+
+// NextElem 在还有元素时返回 true，读到 ] 或出错时返回 false
+func (l *Lexer) NextElem() bool {
+	c := l.peek()
+	if c == ']' {
+		l.pos++
+		l.first = false
+		return false
+	}
+	if !l.first {
+		if c != ',' {
+			l.unexpected()
+			return false
+		}
+		l.pos++
+	}
+	l.first = false
+	return l.err == nil
+}
+
+// unexpectedValue 在期望 want 类型的值时遇到了其他值：合法的其他类型返回 TypeError，否则返回 SyntaxError
+func (l *Lexer) unexpectedValue(want string) {
+	if l.err != nil {
+		return
+	}
+	start := l.pos
+	kind := ""
+	switch c := l.peek(); {
+	case c == '{':
+		kind = "object"
+	case c == '[':
+		kind = "array"
+	case c == '"':
+		kind = "string"
+	case c == 't' || c == 'f':
+		kind = "bool"
+	case c == '-' || '0' <= c && c <= '9':
+		kind = "number"
+	default:
+		l.unexpected()
+		return
+	}
+	// 先确认这个值本身是合法的 JSON
+	l.skip(0)
+	l.pos = start
+	l.typeError(kind, want, start)
+}
+
+// Skip 跳过下一个值，同时检查它的语法
+func (l *Lexer) Skip() {
+	l.skip(0)
+}
+
+// Raw 跳过下一个值并返回它的原始字节
+func (l *Lexer) Raw() []byte {
+	l.skipSpace()
+	start := l.pos
+	l.skip(0)
+	if l.err != nil {
+		return nil
+	}
+	return l.data[start:l.pos]
+}
+
+// Decode 用 encoding/json 把下一个值解码到 v，用于生成器不支持的字段类型
+func (l *Lexer) Decode(v interface{}) {
+	raw := l.Raw()
+	if l.err != nil {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		l.Fail(err)
+	}
+}
+
+func (l *Lexer) skip(depth int) {
+	if depth > maxDepth {
+		l.syntax("exceeded max depth")
+		return
+	}
+	switch c := l.peek(); {
+	case c == '{':
+		l.pos++
+		l.first = true
+		for l.NextKey() {
+			l.skip(depth + 1)
+		}
+	case c == '[':
+		l.pos++
+		l.first = true
+		for l.NextElem() {
+			l.skip(depth + 1)
+		}
+	case c == '"':
+		l.skipString()
+	case c == 't':
+		l.literal("true")
+	case c == 'f':
+		l.literal("false")
+	case c == 'n':
+		l.literal("null")
+	case c == '-' || '0' <= c && c <= '9':
+		l.number()
+	default:
+		l.unexpected()
+	}
+}
+
+// number 读取一个数字并检查语法：-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?
+func (l *Lexer) number() []byte {
+	start := l.pos
+	d := l.data
+	if l.pos < len(d) && d[l.pos] == '-' {
+		l.pos++
+	}
+	switch {
+	case l.pos < len(d) && d[l.pos] == '0':
+		l.pos++
+	case l.pos < len(d) && '1' <= d[l.pos] && d[l.pos] <= '9':
+		l.digits()
+	default:
+		l.unexpected()
+		return nil
+	}
+	if l.pos < len(d) && d[l.pos] == '.' {
+		l.pos++
+		if !l.digits() {
+			l.unexpected()
+			return nil
+		}
+	}
+	if l.pos < len(d) && (d[l.pos] == 'e' || d[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(d) && (d[l.pos] == '+' || d[l.pos] == '-') {
+			l.pos++
+		}
+		if !l.digits() {
+			l.unexpected()
+			return nil
+		}
+	}
+	return d[start:l.pos]
+}
+
+func (l *Lexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.data) && '0' <= l.data[l.pos] && l.data[l.pos] <= '9' {
+		l.pos++
+	}
+	return l.pos > start
+}
+
+// skipString 跳过字符串并检查语法，返回是否需要反转义（包含转义字符或非法的 UTF-8）
+func (l *Lexer) skipString() (escaped bool) {
+	l.pos++ // "
+	d := l.data
+	for l.pos < len(d) {
+		c := d[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return escaped
+		case c == '\\':
+			escaped = true
+			l.pos++
+			if l.pos >= len(d) {
+				l.unexpected()
+				return
+			}
+			switch d[l.pos] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				l.pos++
+			case 'u':
+				l.pos++
+				for i := 0; i < 4; i++ {
+					if l.pos >= len(d) || !isHex(d[l.pos]) {
+						l.syntax("invalid character in \\u hexadecimal character escape")
+						return
+					}
+					l.pos++
+				}
+			default:
+				l.syntax("invalid character in string escape code")
+				return
+			}
+		case c < ' ':
+			l.syntax("invalid character in string literal")
+			return
+		case c < utf8.RuneSelf:
+			l.pos++
+		default:
+			r, size := utf8.DecodeRune(d[l.pos:])
+			if r == utf8.RuneError && size == 1 {
+				escaped = true
+			}
+			l.pos += size
+		}
+	}
+	l.unexpected()
+	return
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// readString 读取字符串并返回反转义后的内容。没有转义字符时直接返回输入的切片，
+// 否则写入 l.buf，在下一次读取字符串之前有效。
+func (l *Lexer) readString() []byte {
+	start := l.pos
+	escaped := l.skipString()
+	if l.err != nil {
+		return nil
+	}
+	s := l.data[start+1 : l.pos-1]
+	if !escaped {
+		return s
+	}
+	l.buf = unquote(l.buf[:0], s)
+	return l.buf
+}
+
+// unquote 与 encoding/json 的 unquoteBytes 相同：
+// 不成对的代理项和非法的 UTF-8 被替换为 U+FFFD
+func unquote(dst, s []byte) []byte {
+	for r := 0; r < len(s); {
+		c := s[r]
+		switch {
+		case c == '\\':
+			r++
+			switch s[r] {
+			case 'b':
+				dst = append(dst, '\b')
+			case 'f':
+				dst = append(dst, '\f')
+			case 'n':
+				dst = append(dst, '\n')
+			case 'r':
+				dst = append(dst, '\r')
+			case 't':
+				dst = append(dst, '\t')
+			case 'u':
+				rr := getu4(s[r+1:])
+				r += 4
+				if utf16.IsSurrogate(rr) {
+					if r+6 < len(s) && s[r+1] == '\\' && s[r+2] == 'u' {
+						if dec := utf16.DecodeRune(rr, getu4(s[r+3:])); dec != unicode.ReplacementChar {
+							r += 6
+							dst = utf8.AppendRune(dst, dec)
+							break
+						}
+					}
+					rr = unicode.ReplacementChar
+				}
+				dst = utf8.AppendRune(dst, rr)
+			default: // " \ /
+				dst = append(dst, s[r])
+			}
+			r++
+		case c < utf8.RuneSelf:
+			dst = append(dst, c)
+			r++
+		default:
+			rr, size := utf8.DecodeRune(s[r:])
+			dst = utf8.AppendRune(dst, rr)
+			r += size
+		}
+	}
+	return dst
+}
+
+func getu4(s []byte) rune {
+	var r rune
+	for _, c := range s[:4] {
+		switch {
+		case '0' <= c && c <= '9':
+			c = c - '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		}
+		r = r*16 + rune(c)
+	}
+	return r
+}
+
+// AppendFoldedName 与 encoding/json 匹配字段名时的折叠规则相同：
+// ASCII 字母转为大写，其他字符转为 ToUpper(ToLower(r))
+func AppendFoldedName(dst, name []byte) []byte {
+	for i := 0; i < len(name); {
+		if c := name[i]; c < utf8.RuneSelf {
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(name[i:])
+		dst = utf8.AppendRune(dst, unicode.ToUpper(unicode.ToLower(r)))
+		i += n
+	}
+	return dst
+}
+
+// FoldName 返回 name 折叠后的结果，供生成器预先计算
+func FoldName(name string) string {
+	return string(AppendFoldedName(nil, []byte(name)))
+}
+
+// 以下方法读取下一个值，ok 为 false 表示值为 null 或者出错。
+// 与 encoding/json 相同，null 不修改字段原有的值，因此生成的代码只在 ok 时赋值。
+// typ 是字段的 Go 类型名，用于错误信息。
+
+// String 读取字符串
+func (l *Lexer) String(typ string) (s string, ok bool) {
+	if l.Null() {
+		return "", false
+	}
+	if l.peek() != '"' {
+		l.unexpectedValue(typ)
+		return "", false
+	}
+	b := l.readString()
+	return string(b), l.err == nil
+}
+
+// Bool 读取布尔值
+func (l *Lexer) Bool(typ string) (b bool, ok bool) {
+	if l.Null() {
+		return false, false
+	}
+	switch l.peek() {
+	case 't':
+		return true, l.literal("true")
+	case 'f':
+		return false, l.literal("false")
+	}
+	l.unexpectedValue(typ)
+	return false, false
+}
+
+// numberLit 读取数字字面量，下一个值不是数字时记录错误并返回 nil
+func (l *Lexer) numberLit(typ string) []byte {
+	if c := l.peek(); c != '-' && (c < '0' || c > '9') {
+		l.unexpectedValue(typ)
+		return nil
+	}
+	return l.number()
+}
+
+// Int 读取 bits 位有符号整数（bits 为 0 表示 int），超出范围或不是整数时记录 TypeError
+func (l *Lexer) Int(bits int, typ string) (n int64, ok bool) {
+	if bits == 0 {
+		bits = strconv.IntSize
+	}
+	if u, neg, end, ok := l.scanInt(); ok {
+		limit := uint64(1) << (bits - 1)
+		if neg && u <= limit {
+			l.pos = end
+			return -int64(u), true
+		}
+		if !neg && u < limit {
+			l.pos = end
+			return int64(u), true
+		}
+	}
+	if l.Null() {
+		return 0, false
+	}
+	start := l.pos
+	lit := l.numberLit(typ)
+	if lit == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(string(lit), 10, bits)
+	if err != nil {
+		l.typeError("number "+string(lit), typ, start)
+		return 0, false
+	}
+	return n, true
+}
+
+// Uint 读取 bits 位无符号整数（bits 为 0 表示 uint）
+func (l *Lexer) Uint(bits int, typ string) (n uint64, ok bool) {
+	if bits == 0 {
+		bits = strconv.IntSize
+	}
+	if u, neg, end, ok := l.scanInt(); ok && !neg && (bits == 64 || u < 1<<bits) {
+		l.pos = end
+		return u, true
+	}
+	if l.Null() {
+		return 0, false
+	}
+	start := l.pos
+	lit := l.numberLit(typ)
+	if lit == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(lit), 10, bits)
+	if err != nil {
+		l.typeError("number "+string(lit), typ, start)
+		return 0, false
+	}
+	return n, true
+}
+
+// scanInt 是整数解析的快速路径：下一个值是不超过 19 位、没有小数和指数的整数时，
+// 在一次扫描中完成语法检查和数值计算，返回绝对值、符号和结束位置，但不移动读取位置。
+// 其他情况（null、小数、指数、前导零、更长的数字）返回 false，交给完整的路径处理并产生相同的结果或错误。
+func (l *Lexer) scanInt() (u uint64, neg bool, end int, ok bool) {
+	if l.err != nil {
+		return
+	}
+	l.skipSpace()
+	d, i := l.data, l.pos
+	if i < len(d) && d[i] == '-' {
+		neg = true
+		i++
+	}
+	start := i
+	for i < len(d) && '0' <= d[i] && d[i] <= '9' {
+		u = u*10 + uint64(d[i]-'0')
+		i++
+	}
+	if n := i - start; n == 0 || n > 19 || n > 1 && d[start] == '0' {
+		return 0, false, 0, false
+	}
+	if i < len(d) && (d[i] == '.' || d[i] == 'e' || d[i] == 'E') {
+		return 0, false, 0, false
+	}
+	return u, neg, i, true
+}
+
+// Float 读取浮点数，bits 为 32 或 64
+func (l *Lexer) Float(bits int, typ string) (f float64, ok bool) {
+	if l.Null() {
+		return 0, false
+	}
+	start := l.pos
+	lit := l.numberLit(typ)
+	if lit == nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(string(lit), bits)
+	if err != nil {
+		l.typeError("number "+string(lit), typ, start)
+		return 0, false
+	}
+	return f, true
+}
+
+// Bytes 把下一个值读入 *p：字符串按 base64 解码，数组按字节数值解码，null 把 *p 置为 nil
+func (l *Lexer) Bytes(p *[]byte) {
+	if l.Null() {
+		if l.err == nil {
+			*p = nil
+		}
+		return
+	}
+	switch l.peek() {
+	case '"':
+		start := l.pos
+		s := l.readString()
+		if l.err != nil {
+			return
+		}
+		b := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
+		n, err := base64.StdEncoding.Decode(b, s)
+		if err != nil {
+			l.Fail(&SyntaxError{Msg: "illegal base64 data: " + err.Error(), Offset: start})
+			return
+		}
+		*p = b[:n]
+	case '[':
+		b := (*p)[:0]
+		l.BeginArray()
+		for l.NextElem() {
+			u, _ := l.Uint(8, "uint8")
+			b = append(b, byte(u))
+		}
+		if l.err == nil {
+			if b == nil {
+				b = []byte{}
+			}
+			*p = b
+		}
+	default:
+		l.unexpectedValue("[]uint8")
+	}
+}
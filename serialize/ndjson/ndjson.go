@@ -0,0 +1,246 @@
+// Package ndjson 并行解码换行分隔的 JSON（NDJSON，每行一条记录）。
+// syncpool_test.go 中的实验每次只解码一条记录，批量处理时整个流程是：
+// 从 io.Reader 切分出一行、解码到对象中、交给后续处理，再把对象放回池中。
+//
+// Decoder 由一个读协程切分行，行的内容复制到 bufpool 的缓冲区中，
+// 多个工作协程把行解码到从 typedpool 中取出的对象里，Next 按输入的顺序返回结果。
+// 同时在途的行数由 Window 限制，消费方处理得慢时读协程会停下来等待，内存占用不会无限增长。
+package ndjson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"highPerformance/concurrency/bufpool"
+	"highPerformance/concurrency/typedpool"
+)
+
+var (
+	// ErrClosed 表示 Decoder 已经被 Close
+	ErrClosed = errors.New("ndjson: decoder is closed")
+	// ErrLineTooLong 表示一行超过了 MaxLine，这一行被跳过
+	ErrLineTooLong = errors.New("ndjson: line too long")
+)
+
+// LineError 表示某一行解码失败，不影响后续的行
+type LineError struct {
+	Line int // 行号，从 1 开始
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("ndjson: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// lines 是所有 Decoder 共享的行缓冲区，超过 1MB 的行每次直接分配
+var lines = bufpool.New(256, 1<<20)
+
+type options struct {
+	workers, window, maxLine int
+}
+
+// Option 是 Decoder 的可选配置
+type Option func(*options)
+
+// Workers 设置解码的协程数，默认为 GOMAXPROCS
+func Workers(n int) Option {
+	return func(o *options) { o.workers = n }
+}
+
+// Window 设置已读取但还没有被 Next 返回的行数上限，默认为协程数的 4 倍且不少于 64
+func Window(n int) Option {
+	return func(o *options) { o.window = n }
+}
+
+// MaxLine 设置一行的最大字节数（包括换行符），默认为 4MB。超过的行被跳过，Next 返回 ErrLineTooLong
+func MaxLine(n int) Option {
+	return func(o *options) { o.maxLine = n }
+}
+
+type job[T any] struct {
+	line int
+	data []byte
+	v    T
+	err  error
+	done chan struct{} // 容量为 1，解码完成后工作协程发送一次
+}
+
+// Decoder 从 io.Reader 中读取 NDJSON 并行解码，需要使用 NewDecoder 创建。
+// Next 和 Close 只能在同一个协程中调用。
+type Decoder[T any] struct {
+	pool      *typedpool.Pool[T]
+	unmarshal func(T, []byte) error
+	maxLine   int
+
+	jobs  sync.Pool // *job[T]
+	work  chan *job[T]
+	order chan *job[T] // 按输入顺序排列，容量即 Window
+	quit  chan struct{}
+	wg    sync.WaitGroup
+
+	err    error // 读协程退出的原因，order 关闭之后才能读取
+	closed bool
+}
+
+// NewDecoder 创建 Decoder 并开始读取 r。每一行调用 unmarshal 解码到 pool.Get() 得到的对象中，
+// 可以直接传入方法表达式，如 (*Student).UnmarshalJSON。空白的行被跳过，但仍然计入行号。
+func NewDecoder[T any](r io.Reader, pool *typedpool.Pool[T], unmarshal func(T, []byte) error, opts ...Option) *Decoder[T] {
+	o := options{workers: runtime.GOMAXPROCS(0), maxLine: 4 << 20}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.workers <= 0 {
+		o.workers = runtime.GOMAXPROCS(0)
+	}
+	if o.window <= 0 {
+		o.window = max(64, 4*o.workers)
+	}
+	d := &Decoder[T]{
+		pool:      pool,
+		unmarshal: unmarshal,
+		maxLine:   o.maxLine,
+		work:      make(chan *job[T], o.workers),
+		order:     make(chan *job[T], o.window),
+		quit:      make(chan struct{}),
+	}
+	d.wg.Add(1 + o.workers)
+	go d.read(bufio.NewReaderSize(r, 64<<10))
+	for i := 0; i < o.workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// read 是读协程，先把行放入 order 再交给工作协程：order 满时在这里阻塞，限制在途的行数
+func (d *Decoder[T]) read(br *bufio.Reader) {
+	defer d.wg.Done()
+	defer close(d.work)
+	defer close(d.order)
+	for line := 1; ; line++ {
+		data, tooLong, err := d.readLine(br)
+		if err != nil && err != io.EOF {
+			// 读取出错时这一行可能不完整，不再解码
+			lines.Put(data)
+			d.err = err
+			return
+		}
+		if tooLong || len(bytes.TrimSpace(data)) > 0 {
+			j, _ := d.jobs.Get().(*job[T])
+			if j == nil {
+				j = &job[T]{done: make(chan struct{}, 1)}
+			}
+			j.line, j.data, j.err = line, data, nil
+			if tooLong {
+				j.err = ErrLineTooLong
+			}
+			select {
+			case d.order <- j:
+			case <-d.quit:
+				lines.Put(data)
+				d.err = ErrClosed
+				return
+			}
+			d.work <- j
+		} else {
+			lines.Put(data)
+		}
+		if err == io.EOF {
+			d.err = io.EOF
+			return
+		}
+	}
+}
+
+// readLine 读取一行到池中的缓冲区，超过 maxLine 时丢弃已读取的部分，继续读到行尾
+func (d *Decoder[T]) readLine(br *bufio.Reader) (data []byte, tooLong bool, err error) {
+	for {
+		frag, err := br.ReadSlice('\n')
+		if !tooLong {
+			if len(data)+len(frag) > d.maxLine {
+				lines.Put(data)
+				data, tooLong = nil, true
+			} else {
+				data = grow(data, len(frag))
+				data = append(data, frag...)
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return data, tooLong, err
+		}
+	}
+}
+
+// grow 保证 b 还能容纳 n 个字节，需要扩容时从池中取出更大的缓冲区，原来的放回池中
+func grow(b []byte, n int) []byte {
+	if len(b)+n <= cap(b) {
+		return b
+	}
+	nb := lines.Get(len(b) + n)[:len(b)]
+	copy(nb, b)
+	lines.Put(b)
+	return nb
+}
+
+func (d *Decoder[T]) worker() {
+	defer d.wg.Done()
+	for j := range d.work {
+		if j.err == nil {
+			j.v = d.pool.Get()
+			if err := d.unmarshal(j.v, j.data); err != nil {
+				d.pool.Put(j.v)
+				var zero T
+				j.v, j.err = zero, err
+			}
+		}
+		lines.Put(j.data)
+		j.data = nil
+		j.done <- struct{}{}
+	}
+}
+
+// Next 按输入的顺序返回下一条记录，调用方使用完之后应当把它放回 pool。
+// 某一行解码失败时返回 *LineError，可以继续调用 Next 读取后面的行；
+// 输入结束时返回 io.EOF，读取 r 出错时返回该错误，此后的调用都返回相同的错误。
+func (d *Decoder[T]) Next() (T, error) {
+	var zero T
+	if d.closed {
+		return zero, ErrClosed
+	}
+	j, ok := <-d.order
+	if !ok {
+		return zero, d.err
+	}
+	<-j.done
+	v, line, err := j.v, j.line, j.err
+	j.v, j.err = zero, nil
+	d.jobs.Put(j)
+	if err != nil {
+		return zero, &LineError{Line: line, Err: err}
+	}
+	return v, nil
+}
+
+// Close 停止读取，把已经解码但还没有被 Next 返回的对象放回 pool，等待所有协程退出。
+// 读协程正阻塞在 r.Read 中时，Close 会等待这次 Read 返回。Close 不会关闭 r。
+func (d *Decoder[T]) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	close(d.quit)
+	for j := range d.order {
+		<-j.done
+		if j.err == nil {
+			d.pool.Put(j.v)
+		}
+	}
+	d.wg.Wait()
+	return nil
+}
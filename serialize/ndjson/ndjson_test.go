@@ -0,0 +1,155 @@
+package ndjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"highPerformance/concurrency/typedpool"
+)
+
+type record struct {
+	N int
+	S string
+}
+
+func newPool() *typedpool.Pool[*record] {
+	return typedpool.New(func() *record { return new(record) }, func(r *record) { *r = record{} })
+}
+
+func unmarshal(r *record, data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+func input(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `{"N":%d,"S":%q}`+"\n", i, strings.Repeat("x", i%300))
+	}
+	return b.String()
+}
+
+// drain 读取全部记录，返回记录和 LineError 的行号
+func drain(t *testing.T, d *Decoder[*record], pool *typedpool.Pool[*record]) (recs []record, bad []int, err error) {
+	t.Helper()
+	for {
+		r, err := d.Next()
+		var le *LineError
+		switch {
+		case errors.As(err, &le):
+			bad = append(bad, le.Line)
+		case err != nil:
+			return recs, bad, err
+		default:
+			recs = append(recs, *r)
+			pool.Put(r)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	pool := newPool()
+	// 解码耗时随机，后面的行可能比前面的行先解码完成
+	slow := func(r *record, data []byte) error {
+		if len(data)%7 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		return unmarshal(r, data)
+	}
+	d := NewDecoder(strings.NewReader(input(2000)), pool, slow, Workers(8), Window(16))
+	defer d.Close()
+	recs, bad, err := drain(t, d, pool)
+	if err != io.EOF || len(bad) != 0 {
+		t.Fatalf("got err %v, bad lines %v", err, bad)
+	}
+	if len(recs) != 2000 {
+		t.Fatalf("got %d records, want 2000", len(recs))
+	}
+	for i, r := range recs {
+		if r.N != i || len(r.S) != i%300 {
+			t.Fatalf("record %d: got %+v", i, r)
+		}
+	}
+	if s := pool.Stats(); s.Gets != s.Puts {
+		t.Fatalf("pool gets %d, puts %d", s.Gets, s.Puts)
+	}
+}
+
+func TestLineErrors(t *testing.T) {
+	in := `{"N":1}` + "\n" +
+		"\n" + // 空行被跳过
+		`{"N":"bad"}` + "\n" +
+		`{"N":2,"S":"` + strings.Repeat("x", 200) + `"}` + "\n" + // 超过 MaxLine
+		"  \r\n" +
+		`{"N":3}` // 最后一行没有换行符
+	pool := newPool()
+	d := NewDecoder(strings.NewReader(in), pool, unmarshal, MaxLine(100))
+	defer d.Close()
+	recs, bad, err := drain(t, d, pool)
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0].N != 1 || recs[1].N != 3 {
+		t.Fatalf("got records %+v", recs)
+	}
+	if fmt.Sprint(bad) != "[3 4]" {
+		t.Fatalf("got bad lines %v, want [3 4]", bad)
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Fatalf("Next after EOF: %v", err)
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	// 超过 bufio.Reader 缓冲区的行由多个片段拼接而成
+	long := strings.Repeat("y", 200<<10)
+	in := `{"N":1,"S":"` + long + `"}` + "\n" + `{"N":2}` + "\n"
+	pool := newPool()
+	d := NewDecoder(strings.NewReader(in), pool, unmarshal)
+	defer d.Close()
+	recs, _, err := drain(t, d, pool)
+	if err != io.EOF || len(recs) != 2 || recs[0].S != long || recs[1].N != 2 {
+		t.Fatalf("got %d records, err %v", len(recs), err)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	boom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader(input(10)+`{"N":10`), iotest.ErrReader(boom))
+	pool := newPool()
+	d := NewDecoder(r, pool, unmarshal)
+	defer d.Close()
+	recs, _, err := drain(t, d, pool)
+	// 出错前的完整行都能读到，不完整的最后一行被丢弃
+	if err != boom || len(recs) != 10 {
+		t.Fatalf("got %d records, err %v", len(recs), err)
+	}
+	if _, err := d.Next(); err != boom {
+		t.Fatalf("Next after error: %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	pool := newPool()
+	d := NewDecoder(strings.NewReader(input(10000)), pool, unmarshal, Window(8))
+	for i := 0; i < 5; i++ {
+		r, err := d.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.Put(r)
+	}
+	d.Close()
+	if _, err := d.Next(); err != ErrClosed {
+		t.Fatalf("Next after Close: %v", err)
+	}
+	// 已解码但没有被取走的对象都放回了池中
+	if s := pool.Stats(); s.Gets != s.Puts {
+		t.Fatalf("pool gets %d, puts %d", s.Gets, s.Puts)
+	}
+}